@@ -0,0 +1,225 @@
+package watcher
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+const (
+	// ArchiveMemberSeparator separates the path of an archive from
+	// the path of a file inside of it in the paths reported by
+	// ScanArchives.
+	ArchiveMemberSeparator = "!/"
+)
+
+// ScanArchives creates a ScanFunc that expands the archives reported by
+// the provided ScanFunc into the files they contain. Zip files (".zip")
+// and tar files (".tar", ".tar.gz", and ".tgz") are supported. Other
+// files are reported as-is.
+//
+// A file inside of an archive is reported using the archive's path, the
+// ArchiveMemberSeparator, and the file's path inside of the archive. For
+// example, "bundle.zip!/config/app.yaml". Its MatchInfo.ModTime is the
+// modification time recorded in the archive, its MatchInfo.Archive is
+// the archive's path, and its MatchedOn value is inherited from the
+// archive. Only regular files are reported. The contents of the files
+// are hashed if the Config's HashContents is true. Otherwise, changes to
+// the files inside of zip files are also detected by their CRC-32
+// checksums.
+//
+// An archive that cannot be read (e.g., because it is still being written)
+// is added to the ScanResult's Failures, so that a Watcher does not report
+// its files as deleted. The other files are reported as usual.
+func ScanArchives(scanFunc ScanFunc) ScanFunc {
+	return func(config Config) (ScanResult, error) {
+		current, err := scanFunc(config)
+		if err != nil {
+			return ScanResult{}, err
+		}
+
+		result := ScanResult{
+			FilePathsToInfo: make(map[string]MatchInfo),
+			Failures:        make(map[string]error),
+		}
+
+		for filePath, failure := range current.Failures {
+			result.Failures[filePath] = failure
+		}
+
+		for filePath, info := range current.FilePathsToInfo {
+			if !isArchive(filePath) {
+				result.FilePathsToInfo[filePath] = info
+				continue
+			}
+
+			members := make(map[string]MatchInfo)
+
+			err := config.expandArchive(info, members)
+			if err != nil {
+				result.Failures[filePath] = &ScanError{
+					reason: "failed to read archive '" + filePath + "' - " + err.Error(),
+				}
+				continue
+			}
+
+			for memberPath, member := range members {
+				result.FilePathsToInfo[memberPath] = member
+			}
+		}
+
+		return result, nil
+	}
+}
+
+func isArchive(filePath string) bool {
+	lower := strings.ToLower(filePath)
+
+	for _, suffix := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expandArchive adds the regular files inside of an archive
+// to the map.
+func (o Config) expandArchive(archive MatchInfo, members map[string]MatchInfo) error {
+	f, err := o.fileSystem().open(archive.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	add := func(name string, member MatchInfo) {
+		name = strings.TrimPrefix(path.Clean("/"+name), "/")
+		if len(name) == 0 {
+			return
+		}
+
+		member.Path = archive.Path + ArchiveMemberSeparator + name
+		member.MatchedOn = archive.MatchedOn
+		member.Captures = archive.Captures
+		member.Root = archive.Root
+		member.Archive = archive.Path
+		members[member.Path] = member
+	}
+
+	if strings.HasSuffix(strings.ToLower(archive.Path), ".zip") {
+		return o.expandZip(f, add)
+	}
+
+	var r io.Reader = f
+
+	if !strings.HasSuffix(strings.ToLower(archive.Path), ".tar") {
+		gzipReader, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+
+		r = gzipReader
+	}
+
+	tarReader := tar.NewReader(r)
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+
+		member := MatchInfo{
+			ModTime: header.ModTime,
+			Size:    header.Size,
+			Mode:    header.FileInfo().Mode(),
+			Uid:     uint32(header.Uid),
+			Gid:     uint32(header.Gid),
+		}
+
+		if o.HashContents {
+			member.Hash, err = o.hashReader(tarReader)
+			if err != nil {
+				return err
+			}
+		}
+
+		add(header.Name, member)
+	}
+}
+
+func (o Config) expandZip(f io.Reader, add func(name string, member MatchInfo)) error {
+	var readerAt io.ReaderAt
+	var size int64
+
+	seeker, isSeeker := f.(io.Seeker)
+	readerAt, isReaderAt := f.(io.ReaderAt)
+	if isSeeker && isReaderAt {
+		end, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
+
+		size = end
+	} else {
+		raw, err := ioutil.ReadAll(f)
+		if err != nil {
+			return err
+		}
+
+		readerAt = bytes.NewReader(raw)
+		size = int64(len(raw))
+	}
+
+	zipReader, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		return err
+	}
+
+	for _, zipFile := range zipReader.File {
+		if !zipFile.Mode().IsRegular() {
+			continue
+		}
+
+		member := MatchInfo{
+			ModTime: zipFile.Modified,
+			Size:    int64(zipFile.UncompressedSize64),
+			Mode:    zipFile.Mode(),
+		}
+
+		if o.HashContents {
+			memberReader, err := zipFile.Open()
+			if err != nil {
+				return err
+			}
+
+			member.Hash, err = o.hashReader(memberReader)
+			memberReader.Close()
+			if err != nil {
+				return err
+			}
+		}
+
+		// The checksum recorded in the archive reveals changes
+		// that preserve the file's modification time and size.
+		member.checksum = zipFile.CRC32
+
+		add(zipFile.Name, member)
+	}
+
+	return nil
+}
@@ -0,0 +1,313 @@
+package watcher
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestScanArchives(t *testing.T) {
+	dirPath := t.TempDir()
+	modTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	writeTestFile(t, path.Join(dirPath, "plain.txt"), "hello")
+	writeTestZip(t, path.Join(dirPath, "bundle.zip"), modTime, "config/app.yaml", "./readme.txt")
+	writeTestTarGz(t, path.Join(dirPath, "bundle.tar.gz"), modTime, "config/app.yaml")
+
+	config := Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{".txt", ".zip", ".tar.gz"},
+	}
+
+	result, err := ScanArchives(ScanFilesInDirectory)(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	exp := map[string]string{
+		path.Join(dirPath, "plain.txt"):                           ".txt",
+		path.Join(dirPath, "bundle.zip") + "!/config/app.yaml":    ".zip",
+		path.Join(dirPath, "bundle.zip") + "!/readme.txt":         ".zip",
+		path.Join(dirPath, "bundle.tar.gz") + "!/config/app.yaml": ".tar.gz",
+	}
+
+	if len(result.FilePathsToInfo) != len(exp) {
+		t.Fatal("Got unexpected scan result -", result.FilePathsToInfo)
+	}
+
+	for filePath, matchedOn := range exp {
+		info, ok := result.FilePathsToInfo[filePath]
+		if !ok {
+			t.Fatal("Scan result is missing", filePath)
+		}
+
+		if info.Path != filePath || info.MatchedOn != matchedOn {
+			t.Fatal("Got unexpected MatchInfo -", info)
+		}
+
+		if len(info.Archive) > 0 && !info.ModTime.Equal(modTime) {
+			t.Fatal("Got unexpected ModTime for", filePath, "-", info.ModTime)
+		}
+	}
+}
+
+func TestScanArchives_Change(t *testing.T) {
+	dirPath := t.TempDir()
+	archivePath := path.Join(dirPath, "bundle.zip")
+	modTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	writeTestZip(t, archivePath, modTime, "a.yaml", "b.yaml")
+
+	w := newDefaultWatcher(Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{".zip"},
+		ScanFunc:     ScanArchives(ScanFilesInDirectory),
+	})
+
+	change := w.scan(w.config)
+	if len(change.UpdatedFilePaths()) != 2 {
+		t.Fatal("Did not get expected updated file paths -", change.UpdatedFilePaths())
+	}
+
+	writeTestZip(t, archivePath, modTime.Add(time.Hour), "a.yaml")
+
+	change = w.scan(w.config)
+	if len(change.UpdatedFilePaths()) != 1 || change.UpdatedFilePaths()[0] != archivePath+"!/a.yaml" {
+		t.Fatal("Did not get expected updated file paths -", change.UpdatedFilePaths())
+	}
+
+	if len(change.DeletedFilePaths()) != 1 || change.DeletedFilePaths()[0] != archivePath+"!/b.yaml" {
+		t.Fatal("Did not get expected deleted file paths -", change.DeletedFilePaths())
+	}
+}
+
+func TestScanArchives_ZipChecksum(t *testing.T) {
+	dirPath := t.TempDir()
+	archivePath := path.Join(dirPath, "bundle.zip")
+	modTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	writeZip := func(contents string) {
+		var buf bytes.Buffer
+		zipWriter := zip.NewWriter(&buf)
+		w, err := zipWriter.CreateHeader(&zip.FileHeader{
+			Name:     "a.yaml",
+			Modified: modTime,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		_, err = w.Write([]byte(contents))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		err = zipWriter.Close()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		writeTestFile(t, archivePath, buf.String())
+	}
+
+	writeZip("hello")
+
+	w := newDefaultWatcher(Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{".zip"},
+		ScanFunc:     ScanArchives(ScanFilesInDirectory),
+	})
+
+	w.scan(w.config)
+
+	writeZip("jello")
+
+	change := w.scan(w.config)
+	if len(change.UpdatedFilePaths()) != 1 || change.UpdatedFilePaths()[0] != archivePath+"!/a.yaml" {
+		t.Fatal("Did not get expected updated file paths -", change.UpdatedFilePaths())
+	}
+}
+
+func TestScanArchives_FS(t *testing.T) {
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	_, err := zipWriter.Create("member.txt")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	zipWriter.Close()
+
+	fsys := fstest.MapFS{
+		"dir/bundle.zip": {Data: buf.Bytes()},
+	}
+
+	result, err := ScanFS(fsys, ScanArchives(ScanFilesInDirectory))(Config{
+		RootDirPath:  "dir",
+		ScanCriteria: []string{".zip"},
+		HashContents: true,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	info, ok := result.FilePathsToInfo["dir/bundle.zip!/member.txt"]
+	if !ok || len(result.FilePathsToInfo) != 1 {
+		t.Fatal("Got unexpected scan result -", result.FilePathsToInfo)
+	}
+
+	if len(info.Hash) == 0 {
+		t.Fatal("File inside of archive was not hashed")
+	}
+}
+
+func TestScanArchives_Malformed(t *testing.T) {
+	dirPath := t.TempDir()
+	archivePath := path.Join(dirPath, "partial.tgz")
+	writeTestFile(t, archivePath, "not an archive")
+	writeTestZip(t, path.Join(dirPath, "bundle.zip"), time.Now(), "a.yaml")
+
+	result, err := ScanArchives(ScanFilesInDirectory)(Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{".tgz", ".zip"},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if result.Failures[archivePath] == nil || len(result.Failures) != 1 {
+		t.Fatal("Malformed archive was not reported as a failure -", result.Failures)
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"a.yaml",
+	})
+}
+
+func TestScanArchives_MalformedChange(t *testing.T) {
+	dirPath := t.TempDir()
+	archivePath := path.Join(dirPath, "bundle.zip")
+	plainPath := path.Join(dirPath, "plain.txt")
+	modTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	writeTestZip(t, archivePath, modTime, "a.yaml", "b.yaml")
+
+	w := newDefaultWatcher(Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{".zip", ".txt"},
+		ScanFunc:     ScanArchives(ScanFilesInDirectory),
+	})
+
+	w.scan(w.config)
+
+	writeTestFile(t, archivePath, "not an archive")
+	writeTestFile(t, plainPath, "hello")
+
+	change := w.scan(w.config)
+	if change.err != nil || change.failures == nil {
+		t.Fatal("Malformed archive was not reported as a failure -", change.err, change.failures)
+	}
+
+	if len(change.DeletedFilePaths()) != 0 {
+		t.Fatal("Files inside of malformed archive were reported as deleted -", change.DeletedFilePaths())
+	}
+
+	if len(change.CreatedFilePaths()) != 1 || change.CreatedFilePaths()[0] != plainPath {
+		t.Fatal("Did not get expected created file paths -", change.CreatedFilePaths())
+	}
+
+	writeTestZip(t, archivePath, modTime, "a.yaml")
+
+	change = w.scan(w.config)
+	if change.failures != nil {
+		t.Fatal("Got unexpected failure -", change.failures)
+	}
+
+	if len(change.DeletedFilePaths()) != 1 || change.DeletedFilePaths()[0] != archivePath+"!/b.yaml" {
+		t.Fatal("Did not get expected deleted file paths -", change.DeletedFilePaths())
+	}
+}
+
+func writeTestZip(t *testing.T, filePath string, modTime time.Time, names ...string) {
+	f, err := os.Create(filePath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer f.Close()
+
+	zipWriter := zip.NewWriter(f)
+
+	for _, name := range names {
+		w, err := zipWriter.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Modified: modTime,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		_, err = w.Write([]byte(name))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	err = zipWriter.Close()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func writeTestTarGz(t *testing.T, filePath string, modTime time.Time, names ...string) {
+	f, err := os.Create(filePath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer f.Close()
+
+	gzipWriter := gzip.NewWriter(f)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err = tarWriter.WriteHeader(&tar.Header{
+		Name:     "config/",
+		Typeflag: tar.TypeDir,
+		Mode:     0700,
+		ModTime:  modTime,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for _, name := range names {
+		err := tarWriter.WriteHeader(&tar.Header{
+			Name:     name,
+			Typeflag: tar.TypeReg,
+			Mode:     0600,
+			Size:     int64(len(name)),
+			ModTime:  modTime,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		_, err = tarWriter.Write([]byte(name))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = gzipWriter.Close()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
@@ -0,0 +1,85 @@
+package watcher
+
+import (
+	"path"
+)
+
+// DefaultTempFilePatterns are the names of the temporary and backup files
+// created by common editors and deploy tools when saving a file, such as
+// Vim's "file.swp" and "file~", Emacs' "#file#" and ".#file", JetBrains
+// IDEs' "file___jb_tmp___", and ".file.tmp".
+var DefaultTempFilePatterns = []string{
+	"*.sw[a-p]",
+	"*~",
+	"4913",
+	"#*#",
+	".#*",
+	"*.tmp",
+	"*___jb_tmp___",
+	"*___jb_old___",
+}
+
+// isTempFile returns true if the file's name matches the Config's
+// TempFilePatterns.
+func (o Config) isTempFile(filePath string) bool {
+	patterns := DefaultTempFilePatterns
+	if len(o.TempFilePatterns) > 0 {
+		patterns = o.TempFilePatterns
+	}
+
+	name := path.Base(filePath)
+
+	for _, pattern := range patterns {
+		if nameGlobMatches(pattern, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withoutTempFiles returns a copy of the ScanResult without
+// temporary files.
+func (o Config) withoutTempFiles(result ScanResult) ScanResult {
+	filtered := ScanResult{
+		FilePathsToInfo: make(map[string]MatchInfo),
+	}
+
+	for filePath, info := range result.FilePathsToInfo {
+		if !o.isTempFile(filePath) {
+			filtered.FilePathsToInfo[filePath] = info
+		}
+	}
+
+	return filtered
+}
+
+// holdDeletes removes deleted files from the Change when AtomicSaves is
+// true, unless they were already held back by the previous scan. It
+// returns the files that are held back, which replace the Watcher's held
+// deletes. A held file that is found again by the next scan is reported
+// as updated if it changed.
+func (o *defaultWatcher) holdDeletes(config Config, change *defaultChange) map[string]bool {
+	if !config.AtomicSaves {
+		o.held = nil
+		return nil
+	}
+
+	held := make(map[string]bool)
+	var deletedInfos []MatchInfo
+
+	for _, info := range change.stateToInfo[deleted] {
+		if o.held[info.Path] {
+			deletedInfos = append(deletedInfos, info)
+			continue
+		}
+
+		held[info.Path] = true
+	}
+
+	change.setState(deleted, deletedInfos)
+
+	o.held = held
+
+	return held
+}
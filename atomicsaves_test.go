@@ -0,0 +1,84 @@
+package watcher
+
+import (
+	"os"
+	"path"
+	"testing"
+)
+
+func TestDefaultWatcher_AtomicSaves(t *testing.T) {
+	dirPath := t.TempDir()
+	filePath := path.Join(dirPath, "file.txt")
+	writeTestFile(t, filePath, "hello")
+
+	w := newDefaultWatcher(Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{searchFileExt, "~", ".swp"},
+		ScanFunc:     ScanFilesInDirectory,
+		AtomicSaves:  true,
+	})
+
+	w.scan(w.config)
+
+	// Save the file the way Vim does.
+	writeTestFile(t, path.Join(dirPath, ".file.txt.swp"), "swap")
+	err := os.Rename(filePath, filePath+"~")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	change := w.scan(w.config)
+	if change.hasChanges() {
+		t.Fatal("Atomic save in progress was reported -", change.DeletedFilePaths(), change.CreatedFilePaths())
+	}
+
+	writeTestFile(t, filePath, "goodbye")
+	err = os.Remove(filePath + "~")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	change = w.scan(w.config)
+	if len(change.CreatedFilePaths()) != 0 || len(change.DeletedFilePaths()) != 0 {
+		t.Fatal("Atomic save was not collapsed -", change.CreatedFilePaths(), change.DeletedFilePaths())
+	}
+
+	if len(change.UpdatedFilePaths()) != 1 || change.UpdatedFilePaths()[0] != filePath {
+		t.Fatal("Did not get expected updated file paths -", change.UpdatedFilePaths())
+	}
+
+	err = os.Remove(filePath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	change = w.scan(w.config)
+	if change.hasChanges() {
+		t.Fatal("Delete was not held back -", change.DeletedFilePaths())
+	}
+
+	change = w.scan(w.config)
+	if len(change.DeletedFilePaths()) != 1 || change.DeletedFilePaths()[0] != filePath {
+		t.Fatal("Did not get expected deleted file paths -", change.DeletedFilePaths())
+	}
+}
+
+func TestConfig_IsTempFile(t *testing.T) {
+	config := Config{}
+
+	for _, name := range []string{"file.txt.swp", "file.txt~", "4913", "#file.txt#", ".#file.txt", ".file.txt.tmp", "file.txt___jb_tmp___"} {
+		if !config.isTempFile(path.Join("dir", name)) {
+			t.Fatal("File was not detected as a temporary file -", name)
+		}
+	}
+
+	if config.isTempFile("dir/file.txt") {
+		t.Fatal("File was detected as a temporary file")
+	}
+
+	config.TempFilePatterns = []string{"*.{part,partial}"}
+
+	if !config.isTempFile("dir/file.txt.part") || config.isTempFile("dir/file.txt~") {
+		t.Fatal("Custom temporary file patterns were not used")
+	}
+}
@@ -0,0 +1,20 @@
+package watcher
+
+// Backend is the mechanism that a Watcher uses to find changes.
+type Backend string
+
+const (
+	// AutoBackend selects NotifyBackend when the operating system can
+	// reliably report changes to the directories that the Config refers
+	// to, and selects PollingBackend otherwise (e.g., for network and
+	// FUSE mounts).
+	AutoBackend Backend = ""
+
+	// PollingBackend scans for changes every Config.RefreshDelay.
+	PollingBackend Backend = "polling"
+
+	// NotifyBackend scans for changes when the operating system reports
+	// that a change occurred. It is currently only supported on Linux,
+	// where it is implemented using inotify.
+	NotifyBackend Backend = "notify"
+)
@@ -0,0 +1,45 @@
+//go:build linux
+// +build linux
+
+package watcher
+
+import (
+	"syscall"
+)
+
+// unreliableFilesystemTypes are the magic numbers of filesystems for which
+// inotify does not report changes made by other machines or processes
+// (see statfs(2)).
+var unreliableFilesystemTypes = map[uint32]string{
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x65735546: "fuse",
+	0x01021997: "9p",
+	0x00c36400: "ceph",
+	0x5346414f: "afs",
+	0x6b414653: "kafs",
+	0x73757245: "coda",
+	0x0000564c: "ncp",
+	0x786f4256: "vboxsf",
+}
+
+// canNotify returns true if the operating system can reliably report
+// changes made to the specified directory.
+func canNotify(dirPath string) bool {
+	var stat syscall.Statfs_t
+
+	err := syscall.Statfs(dirPath, &stat)
+	if err != nil {
+		return false
+	}
+
+	_, unreliable := unreliableFilesystemTypes[uint32(stat.Type)]
+
+	return !unreliable
+}
+
+func newNotifyWatcher(config Config) (Watcher, error) {
+	return NewInotifyWatcher(config)
+}
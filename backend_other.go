@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package watcher
+
+import (
+	"errors"
+)
+
+func canNotify(dirPath string) bool {
+	return false
+}
+
+func newNotifyWatcher(config Config) (Watcher, error) {
+	return &defaultWatcher{}, errors.New("the notify backend is not supported on this platform")
+}
@@ -0,0 +1,200 @@
+package watcher
+
+import (
+	"sort"
+	"time"
+)
+
+// ScanFunc scans for files that match a Config's criteria.
+// See Config.ScanFunc.
+type ScanFunc func(config Config) (ScanResult, error)
+
+// Union creates a ScanFunc that merges the ScanResult of each of the
+// provided ScanFunc. If more than one ScanFunc reports the same file path,
+// the MatchInfo (and therefore the MatchedOn value) reported by the
+// earliest ScanFunc in the argument list is kept. The same is true of the
+// ScanResult's Failures.
+//
+// An error from a ScanFunc is added to the ScanResult's Failures for each
+// of the Config's root directories (or for each of its FilePaths if it
+// has no root directories), so that the results of the other ScanFunc
+// are still reported. The scan fails with the first error if every
+// ScanFunc fails.
+func Union(scanFuncs ...ScanFunc) ScanFunc {
+	return func(config Config) (ScanResult, error) {
+		result := ScanResult{
+			FilePathsToInfo: make(map[string]MatchInfo),
+		}
+
+		var firstErr error
+		failed := 0
+
+		for _, scanFunc := range scanFuncs {
+			current, err := scanFunc(config)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				failed++
+
+				current = ScanResult{
+					Failures: make(map[string]error),
+				}
+
+				for _, filePath := range config.failurePaths() {
+					current.Failures[filePath] = err
+				}
+			}
+
+			for filePath, info := range current.FilePathsToInfo {
+				_, exists := result.FilePathsToInfo[filePath]
+				if !exists {
+					result.FilePathsToInfo[filePath] = info
+				}
+			}
+
+			for filePath, failure := range current.Failures {
+				if result.Failures == nil {
+					result.Failures = make(map[string]error)
+				}
+
+				_, exists := result.Failures[filePath]
+				if !exists {
+					result.Failures[filePath] = failure
+				}
+			}
+		}
+
+		if len(scanFuncs) > 0 && failed == len(scanFuncs) {
+			return ScanResult{}, firstErr
+		}
+
+		return result, nil
+	}
+}
+
+// failurePaths returns the paths that a failed scan is attributed to:
+// the paths of the Config's root directories, or its FilePaths if it
+// has no root directories.
+func (o Config) failurePaths() []string {
+	var filePaths []string
+
+	for _, root := range o.roots() {
+		filePaths = append(filePaths, root.Path)
+	}
+
+	if len(filePaths) == 0 {
+		filePaths = o.FilePaths
+	}
+
+	return filePaths
+}
+
+// Filter creates a ScanFunc that only keeps the files reported by
+// the provided ScanFunc for which keep returns true.
+func Filter(scanFunc ScanFunc, keep func(info MatchInfo) bool) ScanFunc {
+	return func(config Config) (ScanResult, error) {
+		current, err := scanFunc(config)
+		if err != nil {
+			return ScanResult{}, err
+		}
+
+		result := ScanResult{
+			FilePathsToInfo: make(map[string]MatchInfo),
+			Failures:        current.Failures,
+		}
+
+		for filePath, info := range current.FilePathsToInfo {
+			if keep(info) {
+				result.FilePathsToInfo[filePath] = info
+			}
+		}
+
+		return result, nil
+	}
+}
+
+// Exclude creates a ScanFunc that removes the files reported by
+// the provided ScanFunc for which exclude returns true.
+func Exclude(scanFunc ScanFunc, exclude func(info MatchInfo) bool) ScanFunc {
+	return Filter(scanFunc, func(info MatchInfo) bool {
+		return !exclude(info)
+	})
+}
+
+// Map creates a ScanFunc that rewrites the path of each file reported by
+// the provided ScanFunc. A file is removed if rewrite returns an empty
+// string. If more than one file is rewritten to the same path, the MatchInfo
+// of the file whose original path sorts first is kept.
+func Map(scanFunc ScanFunc, rewrite func(filePath string) string) ScanFunc {
+	return func(config Config) (ScanResult, error) {
+		current, err := scanFunc(config)
+		if err != nil {
+			return ScanResult{}, err
+		}
+
+		filePaths := make([]string, 0, len(current.FilePathsToInfo))
+		for filePath := range current.FilePathsToInfo {
+			filePaths = append(filePaths, filePath)
+		}
+		sort.Strings(filePaths)
+
+		result := ScanResult{
+			FilePathsToInfo: make(map[string]MatchInfo),
+			Failures:        current.Failures,
+		}
+
+		for _, filePath := range filePaths {
+			newFilePath := rewrite(filePath)
+			if len(newFilePath) == 0 {
+				continue
+			}
+
+			_, exists := result.FilePathsToInfo[newFilePath]
+			if exists {
+				continue
+			}
+
+			info := current.FilePathsToInfo[filePath]
+			info.Path = newFilePath
+			result.FilePathsToInfo[newFilePath] = info
+		}
+
+		return result, nil
+	}
+}
+
+// WithTimeout creates a ScanFunc that fails with a ScanError if the
+// provided ScanFunc does not finish within the timeout. The provided
+// ScanFunc is not interrupted, and its result is discarded when it
+// eventually finishes.
+func WithTimeout(scanFunc ScanFunc, timeout time.Duration) ScanFunc {
+	return func(config Config) (ScanResult, error) {
+		type scanOutput struct {
+			result ScanResult
+			err    error
+		}
+
+		done := make(chan scanOutput, 1)
+
+		go func() {
+			result, err := scanFunc(config)
+			done <- scanOutput{
+				result: result,
+				err:    err,
+			}
+		}()
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case output := <-done:
+			return output.result, output.err
+		case <-timer.C:
+			return ScanResult{}, &ScanError{
+				reason: "scan did not finish within " + timeout.String(),
+			}
+		}
+	}
+}
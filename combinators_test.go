@@ -0,0 +1,159 @@
+package watcher
+
+import (
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnion(t *testing.T) {
+	config := Config{
+		RootDirPath:  testDataDirPath(),
+		ScanCriteria: []string{searchFileExt, "1.txt"},
+	}
+
+	byName := func(config Config) (ScanResult, error) {
+		config.ScanCriteria = []string{"1.txt"}
+		return ScanFilesRecursively(config)
+	}
+
+	result, err := Union(byName, ScanFilesInDirectory, ScanFilesInSubdirectories)(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"file1.txt",
+		"file2.txt",
+		"subdirfile1.txt",
+		"subdirfile2.txt",
+	})
+
+	for filePath, info := range result.FilePathsToInfo {
+		exp := searchFileExt
+		if strings.HasSuffix(filePath, "1.txt") {
+			exp = "1.txt"
+		}
+
+		if info.MatchedOn != exp {
+			t.Fatal("Got unexpected MatchedOn for", filePath, "-", info.MatchedOn)
+		}
+	}
+
+	missingDirPath := path.Join(config.RootDirPath, "missing")
+	existing := path.Join(config.RootDirPath, "file1.txt")
+	config.RootDirPath = missingDirPath
+	config.FilePaths = []string{existing}
+
+	result, err = Union(ScanFilePaths, ScanFilesInDirectory)(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if result.Failures[missingDirPath] == nil || len(result.Failures) != 1 {
+		t.Fatal("Failed scan function was not reported as a failure -", result.Failures)
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"file1.txt",
+	})
+
+	_, err = Union(ScanFilesInDirectory, ScanFilesRecursively)(config)
+	if err == nil {
+		t.Fatal("Union did not return error")
+	}
+}
+
+func TestFilterAndExclude(t *testing.T) {
+	config := Config{
+		RootDirPath:  testDataDirPath(),
+		ScanCriteria: []string{searchFileExt},
+	}
+
+	isFile1 := func(info MatchInfo) bool {
+		return path.Base(info.Path) == "file1.txt"
+	}
+
+	result, err := Filter(ScanFilesInDirectory, isFile1)(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{"file1.txt"})
+
+	result, err = Exclude(ScanFilesInDirectory, isFile1)(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{"file2.txt"})
+}
+
+func TestMap(t *testing.T) {
+	config := Config{
+		RootDirPath:  testDataDirPath(),
+		ScanCriteria: []string{searchFileExt},
+	}
+
+	result, err := Map(ScanFilesInDirectory, func(filePath string) string {
+		if path.Base(filePath) == "file2.txt" {
+			return ""
+		}
+
+		return "/virtual/" + path.Base(filePath)
+	})(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	info, ok := result.FilePathsToInfo["/virtual/file1.txt"]
+	if !ok || len(result.FilePathsToInfo) != 1 {
+		t.Fatal("Got unexpected result -", result.FilePathsToInfo)
+	}
+
+	if info.Path != "/virtual/file1.txt" {
+		t.Fatal("Path was not rewritten -", info.Path)
+	}
+
+	fixed := func(config Config) (ScanResult, error) {
+		return ScanResult{
+			FilePathsToInfo: map[string]MatchInfo{
+				"/b": {Path: "/b", MatchedOn: "b"},
+				"/a": {Path: "/a", MatchedOn: "a"},
+				"/c": {Path: "/c", MatchedOn: "c"},
+			},
+		}, nil
+	}
+
+	result, err = Map(fixed, func(filePath string) string {
+		return "/same"
+	})(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if result.FilePathsToInfo["/same"].MatchedOn != "a" {
+		t.Fatal("Got unexpected conflict winner -", result.FilePathsToInfo["/same"])
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	slow := func(config Config) (ScanResult, error) {
+		time.Sleep(time.Second)
+		return ScanResult{}, nil
+	}
+
+	_, err := WithTimeout(slow, 10*time.Millisecond)(Config{})
+	if err == nil {
+		t.Fatal("Timeout did not generate an error")
+	}
+
+	_, err = WithTimeout(ScanFilesInDirectory, time.Minute)(Config{
+		RootDirPath:  testDataDirPath(),
+		ScanCriteria: []string{searchFileExt},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
@@ -0,0 +1,196 @@
+package watcher
+
+import (
+	"errors"
+	"regexp"
+)
+
+// CriteriaType determines how a Config's ScanCriteria are matched
+// against files.
+type CriteriaType string
+
+const (
+	// SuffixCriteria matches files whose names end with one of
+	// the ScanCriteria. This is the default.
+	SuffixCriteria CriteriaType = ""
+
+	// GlobCriteria matches files using glob patterns. In addition to
+	// the syntax supported by path.Match, patterns may contain "**"
+	// to match any number of directories, and brace alternations
+	// such as "*.{yaml,yml}".
+	//
+	// A pattern that does not contain a '/' is matched against the
+	// file's name. Otherwise, it is matched against the file's path
+	// relative to the root directory. For example, "configs/**/*.yaml"
+	// matches YAML files anywhere beneath the "configs" directory.
+	//
+	// Patterns are evaluated in order, and the last pattern that matches
+	// a file wins. A pattern that begins with '!' excludes files that
+	// were matched by a previous pattern. For example, "**/*.go" followed
+	// by "!**/vendor/**" matches Go files that are not in a vendor
+	// directory. A leading '!' can be escaped with a '\'.
+	GlobCriteria CriteriaType = "glob"
+
+	// RegexpCriteria matches file names using regular expressions
+	// (see the regexp package). For example, `^report-\d{8}\.csv$`
+	// matches "report-20200101.csv". The first expression that matches
+	// a file wins. The values of named capture groups are stored in
+	// the file's MatchInfo.Captures.
+	RegexpCriteria CriteriaType = "regexp"
+
+	// PathRegexpCriteria is the same as RegexpCriteria, except that
+	// expressions are matched against the file's path relative to
+	// the root directory (e.g., "reports/2020/report.csv").
+	PathRegexpCriteria CriteriaType = "path-regexp"
+)
+
+// matchCriteria returns the criterion that a file matches, and true
+// if the file matches the Config's ScanCriteria. The relPath is the
+// file's path relative to the root directory. The regexps are the
+// compiled ScanCriteria (see criteriaRegexps) if they are regular
+// expressions. The returned map contains the values of named capture
+// groups, if any.
+func (o Config) matchCriteria(name string, relPath string, regexps []*regexp.Regexp) (string, map[string]string, bool) {
+	switch o.CriteriaType {
+	case GlobCriteria:
+		criterion, matches := matchesGlobs(name, relPath, o.ScanCriteria)
+		return criterion, nil, matches
+	case RegexpCriteria:
+		return matchesRegexps(name, regexps)
+	case PathRegexpCriteria:
+		return matchesRegexps(relPath, regexps)
+	default:
+		criterion, matches := matchesSuffixes(name, o.ScanCriteria)
+		return criterion, nil, matches
+	}
+}
+
+// criteriaRegexps returns the compiled ScanCriteria if they are regular
+// expressions. Criteria that were not previously compiled by
+// compileCriteria (e.g., because the ScanFunc was called directly)
+// are compiled on demand.
+func (o Config) criteriaRegexps() []*regexp.Regexp {
+	switch o.CriteriaType {
+	case RegexpCriteria, PathRegexpCriteria:
+	default:
+		return nil
+	}
+
+	var regexps []*regexp.Regexp
+
+	for _, expression := range o.ScanCriteria {
+		re, compiled := o.regexps[expression]
+		if !compiled {
+			var err error
+			re, err = regexp.Compile(expression)
+			if err != nil {
+				continue
+			}
+		}
+
+		regexps = append(regexps, re)
+	}
+
+	return regexps
+}
+
+// compileCriteria compiles the ScanCriteria of the Config and of its
+// Roots if they are regular expressions.
+func (o *Config) compileCriteria() error {
+	switch o.CriteriaType {
+	case RegexpCriteria, PathRegexpCriteria:
+		o.regexps = make(map[string]*regexp.Regexp)
+
+		for _, criteria := range o.criteriaLists() {
+			regexps, err := compileRegexps(criteria)
+			if err != nil {
+				return err
+			}
+
+			for _, re := range regexps {
+				o.regexps[re.String()] = re
+			}
+		}
+	}
+
+	return nil
+}
+
+func (o Config) validateCriteria() error {
+	for _, criteria := range o.criteriaLists() {
+		switch o.CriteriaType {
+		case SuffixCriteria:
+		case GlobCriteria:
+			for _, pattern := range criteria {
+				err := validateGlob(pattern)
+				if err != nil {
+					return err
+				}
+			}
+		case RegexpCriteria, PathRegexpCriteria:
+			_, err := compileRegexps(criteria)
+			if err != nil {
+				return err
+			}
+		default:
+			return errors.New("the criteria type '" + string(o.CriteriaType) + "' is not supported")
+		}
+	}
+
+	return nil
+}
+
+// criteriaLists returns the ScanCriteria of the Config and of its Roots.
+func (o Config) criteriaLists() [][]string {
+	lists := [][]string{o.ScanCriteria}
+
+	for _, root := range o.Roots {
+		lists = append(lists, root.ScanCriteria)
+	}
+
+	return lists
+}
+
+func compileRegexps(expressions []string) ([]*regexp.Regexp, error) {
+	var regexps []*regexp.Regexp
+
+	for _, expression := range expressions {
+		re, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, errors.New("malformed regular expression '" + expression + "' - " + err.Error())
+		}
+
+		regexps = append(regexps, re)
+	}
+
+	return regexps, nil
+}
+
+// matchesRegexps returns the first regular expression that matches s,
+// along with the values of its named capture groups.
+func matchesRegexps(s string, regexps []*regexp.Regexp) (string, map[string]string, bool) {
+	for _, re := range regexps {
+		submatches := re.FindStringSubmatch(s)
+		if submatches == nil {
+			continue
+		}
+
+		var captures map[string]string
+
+		for i, name := range re.SubexpNames() {
+			if len(name) == 0 {
+				continue
+			}
+
+			if captures == nil {
+				captures = make(map[string]string)
+			}
+
+			captures[name] = submatches[i]
+		}
+
+		return re.String(), captures, true
+	}
+
+	return "", nil, false
+}
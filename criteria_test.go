@@ -0,0 +1,89 @@
+package watcher
+
+import (
+	"testing"
+)
+
+func TestScanFilesRecursively_RegexpCriteria(t *testing.T) {
+	config := Config{
+		RootDirPath:  testDataDirPath(),
+		ScanCriteria: []string{`^subdirfile(?P<number>\d)\.txt$`},
+		CriteriaType: RegexpCriteria,
+	}
+
+	err := config.compileCriteria()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	result, err := ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"subdirfile1.txt",
+		"subdirfile2.txt",
+	})
+
+	for _, info := range result.FilePathsToInfo {
+		if info.MatchedOn != config.ScanCriteria[0] {
+			t.Fatal("Got unexpected MatchedOn -", info.MatchedOn)
+		}
+
+		if info.Path[len(info.Path)-5:len(info.Path)-4] != info.Captures["number"] {
+			t.Fatal("Got unexpected captures -", info.Captures)
+		}
+	}
+}
+
+func TestScanFilesRecursively_PathRegexpCriteria(t *testing.T) {
+	config := Config{
+		RootDirPath:  testDataDirPath(),
+		ScanCriteria: []string{`^(?P<dir>[^/]+)/[^/]+\.txt$`},
+		CriteriaType: PathRegexpCriteria,
+	}
+
+	// The criteria are intentionally not compiled ahead of time.
+	result, err := ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"subdirfile1.txt",
+		"subdirfile2.txt",
+	})
+
+	for _, info := range result.FilePathsToInfo {
+		if info.Captures["dir"] != "subdir" {
+			t.Fatal("Got unexpected captures -", info.Captures)
+		}
+	}
+}
+
+func TestConfig_IsValidRegexpCriteria(t *testing.T) {
+	config := Config{
+		RootDirPath:  "fdf",
+		ScanCriteria: []string{`report-(\d{8}\.csv`},
+		CriteriaType: RegexpCriteria,
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesInDirectory,
+	}
+
+	_, err := NewWatcher(config)
+	if err == nil {
+		t.Fatal("Malformed regular expression did not generate an error")
+	}
+
+	config.ScanCriteria = []string{`^report-\d{8}\.csv$`}
+	w, err := NewWatcher(config)
+	if err != nil {
+		t.Fatal("Valid config generated an error -", err.Error())
+	}
+	defer w.Destroy()
+
+	if len(w.Config().regexps) != 1 {
+		t.Fatal("Regular expressions were not compiled")
+	}
+}
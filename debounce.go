@@ -0,0 +1,254 @@
+package watcher
+
+import (
+	"sort"
+	"time"
+)
+
+// debouncedChange is a Change that is being accumulated
+// (see Config.DebounceDelay).
+type debouncedChange struct {
+	first  time.Time
+	last   time.Time
+	merged *defaultChange
+}
+
+// debounce accumulates a Change if the Config's DebounceDelay is specified.
+// It returns the Change to emit, which contains no changes if the
+// accumulated changes are not due yet.
+func (o *defaultWatcher) debounce(config Config, change *defaultChange, now time.Time) *defaultChange {
+	if config.DebounceDelay <= 0 {
+		return change
+	}
+
+	if change.hasChanges() {
+		if o.batch == nil {
+			o.batch = &debouncedChange{
+				first:  now,
+				merged: change,
+			}
+		} else {
+			o.batch.merged = mergeChanges(o.batch.merged, change)
+		}
+
+		o.batch.last = now
+	}
+
+	if o.batch == nil || !config.debounceDue(*o.batch, now) {
+		return &defaultChange{
+			scanResult: change.scanResult,
+		}
+	}
+
+	merged := o.batch.merged
+	o.batch = nil
+
+	return merged
+}
+
+// debounceDue returns true if accumulated changes should be reported.
+func (o Config) debounceDue(batch debouncedChange, now time.Time) bool {
+	if now.Sub(batch.last) >= o.DebounceDelay {
+		return true
+	}
+
+	return o.DebounceMaxWait > 0 && now.Sub(batch.first) >= o.DebounceMaxWait
+}
+
+// debounceDelay returns the time to wait until accumulated changes are
+// due. The second return value is false if there are no accumulated
+// changes.
+func (o *defaultWatcher) debounceDelay(config Config) (time.Duration, bool) {
+	if o.batch == nil {
+		return 0, false
+	}
+
+	delay := time.Until(o.batch.last.Add(config.DebounceDelay))
+
+	if config.DebounceMaxWait > 0 {
+		maxDelay := time.Until(o.batch.first.Add(config.DebounceMaxWait))
+		if maxDelay < delay {
+			delay = maxDelay
+		}
+	}
+
+	return delay, true
+}
+
+// mergeChanges merges a Change into the Change that preceded it. The
+// result contains the final state of each file.
+func mergeChanges(prev *defaultChange, next *defaultChange) *defaultChange {
+	states := make(map[string]changeState)
+	infos := make(map[string]MatchInfo)
+	attributes := make(map[string]MatchInfo)
+	renames := make(map[string]rename)
+
+	// The information from before the first Change is kept.
+	previousInfos := make(map[string]MatchInfo)
+	for filePath, info := range next.previous {
+		previousInfos[filePath] = info
+	}
+
+	for filePath, info := range prev.previous {
+		previousInfos[filePath] = info
+	}
+
+	// remember records the information about a file from before the
+	// merged Change for a file that is reported as updated, unless
+	// it is already known.
+	remember := func(filePath string, info MatchInfo) {
+		if _, known := previousInfos[filePath]; !known {
+			previousInfos[filePath] = info
+		}
+	}
+
+	set := func(state changeState, info MatchInfo) {
+		states[info.Path] = state
+		infos[info.Path] = info
+	}
+
+	unset := func(filePath string) {
+		delete(states, filePath)
+		delete(infos, filePath)
+	}
+
+	for _, state := range []changeState{created, existing, updated, deleted} {
+		for _, info := range prev.stateToInfo[state] {
+			set(state, info)
+		}
+	}
+
+	for _, info := range prev.stateToInfo[attributeChanged] {
+		attributes[info.Path] = info
+	}
+
+	for _, r := range prev.renames {
+		renames[r.new.Path] = r
+	}
+
+	for _, r := range next.renames {
+		oldPath := r.old.Path
+
+		// The file may have been renamed over a deleted file.
+		replaced := states[r.new.Path] == deleted
+		if replaced {
+			remember(r.new.Path, infos[r.new.Path])
+			unset(r.new.Path)
+		}
+
+		if previous, renamed := renames[oldPath]; renamed {
+			delete(renames, oldPath)
+
+			r.old = previous.old
+			if r.old.Path != r.new.Path {
+				renames[r.new.Path] = r
+			}
+		} else if states[oldPath] == created {
+			unset(oldPath)
+
+			if replaced {
+				set(updated, r.new)
+			} else {
+				set(created, r.new)
+			}
+		} else {
+			if states[oldPath] == updated {
+				if info, known := previousInfos[oldPath]; known {
+					remember(r.new.Path, info)
+				}
+
+				unset(oldPath)
+				set(updated, r.new)
+			}
+
+			renames[r.new.Path] = r
+		}
+
+		if info, changed := attributes[oldPath]; changed {
+			delete(attributes, oldPath)
+			info.Path = r.new.Path
+			attributes[r.new.Path] = info
+		}
+	}
+
+	for _, info := range next.stateToInfo[deleted] {
+		delete(attributes, info.Path)
+
+		if previous, renamed := renames[info.Path]; renamed {
+			delete(renames, info.Path)
+			unset(info.Path)
+
+			if states[previous.old.Path] == created {
+				set(updated, infos[previous.old.Path])
+			} else {
+				set(deleted, previous.old)
+			}
+
+			continue
+		}
+
+		if states[info.Path] == created {
+			unset(info.Path)
+			continue
+		}
+
+		set(deleted, info)
+	}
+
+	for _, info := range next.stateToInfo[created] {
+		if states[info.Path] == deleted {
+			remember(info.Path, infos[info.Path])
+			set(updated, info)
+		} else {
+			set(created, info)
+		}
+	}
+
+	for _, info := range next.stateToInfo[existing] {
+		set(existing, info)
+	}
+
+	for _, info := range next.stateToInfo[updated] {
+		switch states[info.Path] {
+		case created, existing:
+			set(states[info.Path], info)
+		default:
+			set(updated, info)
+		}
+	}
+
+	for _, info := range next.stateToInfo[attributeChanged] {
+		switch states[info.Path] {
+		case created, existing:
+			set(states[info.Path], info)
+		default:
+			attributes[info.Path] = info
+		}
+	}
+
+	merged := &defaultChange{
+		reconciled:  prev.reconciled || next.reconciled,
+		time:        next.time,
+		scanResult:  next.scanResult,
+		stateToInfo: make(map[changeState][]MatchInfo),
+		previous:    previousInfos,
+	}
+
+	for filePath, state := range states {
+		merged.stateToInfo[state] = append(merged.stateToInfo[state], infos[filePath])
+	}
+
+	for _, info := range attributes {
+		merged.stateToInfo[attributeChanged] = append(merged.stateToInfo[attributeChanged], info)
+	}
+
+	for _, r := range renames {
+		merged.renames = append(merged.renames, r)
+	}
+
+	sort.Slice(merged.renames, func(i, j int) bool {
+		return merged.renames[i].new.Path < merged.renames[j].new.Path
+	})
+
+	return merged
+}
@@ -0,0 +1,202 @@
+package watcher
+
+import (
+	"path"
+	"testing"
+	"time"
+)
+
+func TestMergeChanges(t *testing.T) {
+	info := func(filePath string) MatchInfo {
+		return MatchInfo{
+			Path: filePath,
+		}
+	}
+
+	changeOf := func(stateToPaths map[changeState][]string, renames ...rename) *defaultChange {
+		change := &defaultChange{
+			stateToInfo: make(map[changeState][]MatchInfo),
+			renames:     renames,
+		}
+
+		for state, filePaths := range stateToPaths {
+			for _, filePath := range filePaths {
+				change.stateToInfo[state] = append(change.stateToInfo[state], info(filePath))
+			}
+		}
+
+		return change
+	}
+
+	merged := mergeChanges(
+		changeOf(map[changeState][]string{
+			created: {"created-deleted", "created-updated"},
+			updated: {"updated-deleted"},
+			deleted: {"deleted-created"},
+		}),
+		changeOf(map[changeState][]string{
+			created: {"deleted-created"},
+			updated: {"created-updated"},
+			deleted: {"created-deleted", "updated-deleted"},
+		}))
+
+	assertStrings(t, "created", merged.CreatedFilePaths(), []string{"created-updated"})
+	assertStrings(t, "updated", merged.UpdatedFilePaths(), []string{"deleted-created"})
+	assertStrings(t, "deleted", merged.DeletedFilePaths(), []string{"updated-deleted"})
+
+	merged = mergeChanges(
+		changeOf(map[changeState][]string{
+			created: {"new"},
+		}, rename{old: info("a"), new: info("b")}, rename{old: info("x"), new: info("y")}),
+		changeOf(nil,
+			rename{old: info("b"), new: info("c")},
+			rename{old: info("new"), new: info("moved")}))
+
+	renames := merged.RenamedFilePaths()
+	if len(renames) != 2 || renames[0] != (Rename{OldPath: "a", NewPath: "c"}) || renames[1] != (Rename{OldPath: "x", NewPath: "y"}) {
+		t.Fatal("Did not get expected renames -", renames)
+	}
+
+	assertStrings(t, "created", merged.CreatedFilePaths(), []string{"moved"})
+
+	merged = mergeChanges(
+		changeOf(nil, rename{old: info("a"), new: info("b")}),
+		changeOf(map[changeState][]string{
+			deleted: {"b"},
+		}))
+
+	if len(merged.RenamedFilePaths()) != 0 {
+		t.Fatal("Deleted file is still renamed -", merged.RenamedFilePaths())
+	}
+
+	assertStrings(t, "deleted", merged.DeletedFilePaths(), []string{"a"})
+}
+
+func TestMergeChanges_Events(t *testing.T) {
+	old := MatchInfo{Path: "replaced", Size: 1}
+	current := MatchInfo{Path: "replaced", Size: 2}
+
+	merged := mergeChanges(
+		&defaultChange{
+			stateToInfo: map[changeState][]MatchInfo{deleted: {old}},
+		},
+		&defaultChange{
+			stateToInfo: map[changeState][]MatchInfo{created: {current}},
+		})
+
+	events := merged.Events()
+	if len(events) != 1 || events[0].Op != OpWrite || events[0].Old == nil || events[0].Old.Size != old.Size {
+		t.Fatal("Did not get expected events for a deleted and created file -", events)
+	}
+
+	old = MatchInfo{Path: "a", Size: 1}
+	modified := MatchInfo{Path: "a", Size: 2}
+	renamed := MatchInfo{Path: "b", Size: 2}
+
+	merged = mergeChanges(
+		&defaultChange{
+			stateToInfo: map[changeState][]MatchInfo{updated: {modified}},
+			previous:    map[string]MatchInfo{"a": old},
+		},
+		&defaultChange{
+			renames: []rename{{old: modified, new: renamed}},
+		})
+
+	events = merged.Events()
+	if len(events) != 1 || events[0].Op != OpWrite|OpRename || events[0].Old == nil || events[0].Old.Path != "a" {
+		t.Fatal("Did not get expected events for an updated and renamed file -", events)
+	}
+}
+
+func TestDefaultWatcher_Debounce(t *testing.T) {
+	w := newDefaultWatcher(Config{})
+	config := Config{
+		DebounceDelay:   50 * time.Millisecond,
+		DebounceMaxWait: 100 * time.Millisecond,
+	}
+
+	changeOf := func(state changeState, filePath string) *defaultChange {
+		return &defaultChange{
+			stateToInfo: map[changeState][]MatchInfo{
+				state: {{Path: filePath}},
+			},
+		}
+	}
+
+	noChange := &defaultChange{}
+	start := time.Now()
+
+	if w.debounce(config, changeOf(updated, "a"), start).hasChanges() {
+		t.Fatal("Change was not debounced")
+	}
+
+	if w.debounce(config, noChange, start.Add(40*time.Millisecond)).hasChanges() {
+		t.Fatal("Change was emitted before the debounce delay")
+	}
+
+	change := w.debounce(config, noChange, start.Add(50*time.Millisecond))
+	assertStrings(t, "updated", change.UpdatedFilePaths(), []string{"a"})
+
+	// A constant trickle of changes is emitted after the maximum wait.
+	for i := 0; i < 10; i++ {
+		now := start.Add(time.Duration(i*40) * time.Millisecond)
+
+		change = w.debounce(config, changeOf(created, path.Join("dir", string(rune('a'+i)))), now)
+		if change.hasChanges() {
+			if i != 3 {
+				t.Fatal("Change was emitted at unexpected time -", i)
+			}
+			break
+		}
+	}
+
+	if len(change.CreatedFilePaths()) != 4 {
+		t.Fatal("Did not get expected created file paths -", change.CreatedFilePaths())
+	}
+}
+
+func TestDefaultWatcherDebounce_Start(t *testing.T) {
+	dirPath := t.TempDir()
+
+	config := Config{
+		RefreshDelay:  10 * time.Millisecond,
+		DebounceDelay: 200 * time.Millisecond,
+		RootDirPath:   dirPath,
+		ScanCriteria:  []string{searchFileExt},
+		Changes:       make(chan Change),
+		ScanFunc:      ScanFilesInDirectory,
+	}
+	w, err := NewWatcher(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer w.Destroy()
+
+	w.Start()
+
+	for _, name := range []string{"1.txt", "2.txt", "3.txt"} {
+		time.Sleep(50 * time.Millisecond)
+		writeTestFile(t, path.Join(dirPath, name), "hello")
+	}
+
+	select {
+	case change := <-config.Changes:
+		if len(change.CreatedFilePaths())+len(change.UpdatedFilePaths()) != 3 {
+			t.Fatal("Changes were not merged -", change.CreatedFilePaths(), change.UpdatedFilePaths())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for a change")
+	}
+}
+
+func assertStrings(t *testing.T, name string, actual []string, exp []string) {
+	if len(actual) != len(exp) {
+		t.Fatal("Did not get expected", name, "-", actual)
+	}
+
+	for i := range exp {
+		if actual[i] != exp[i] {
+			t.Fatal("Did not get expected", name, "-", actual)
+		}
+	}
+}
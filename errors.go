@@ -1,5 +1,10 @@
 package watcher
 
+import (
+	"sort"
+	"strings"
+)
+
 type ScanError struct {
 	reason         string
 	rootReadFailed bool
@@ -12,3 +17,30 @@ func (o ScanError) Error() string {
 func (o ScanError) RootDirectoryReadFailed() bool {
 	return o.rootReadFailed
 }
+
+// failuresError returns a ScanError that describes the failures in
+// a ScanResult, sorted by path.
+func failuresError(failures map[string]error) error {
+	var filePaths []string
+	for filePath := range failures {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+
+	var reasons []string
+	rootReadFailed := false
+
+	for _, filePath := range filePaths {
+		err := failures[filePath]
+		reasons = append(reasons, err.Error())
+
+		if sErr, is := err.(*ScanError); is && sErr.rootReadFailed {
+			rootReadFailed = true
+		}
+	}
+
+	return &ScanError{
+		reason:         strings.Join(reasons, "; "),
+		rootReadFailed: rootReadFailed,
+	}
+}
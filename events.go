@@ -0,0 +1,177 @@
+package watcher
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Op describes the operations that were performed on a file. It is a
+// bitmask, so an Event may describe more than one operation.
+type Op uint32
+
+const (
+	// OpCreate means that the file was created, or that the Watcher
+	// found it in its first scan.
+	OpCreate Op = 1 << iota
+
+	// OpWrite means that the file's contents changed.
+	OpWrite
+
+	// OpRemove means that the file was deleted.
+	OpRemove
+
+	// OpRename means that the file was renamed or moved (see Rename).
+	OpRename
+
+	// OpChmod means that the file's mode, owner, or group changed.
+	OpChmod
+)
+
+// Has returns true if the Op includes the specified Op.
+func (o Op) Has(op Op) bool {
+	return o&op == op
+}
+
+func (o Op) String() string {
+	var names []string
+
+	for _, op := range []struct {
+		op   Op
+		name string
+	}{
+		{op: OpCreate, name: "CREATE"},
+		{op: OpWrite, name: "WRITE"},
+		{op: OpRemove, name: "REMOVE"},
+		{op: OpRename, name: "RENAME"},
+		{op: OpChmod, name: "CHMOD"},
+	} {
+		if o.Has(op.op) {
+			names = append(names, op.name)
+		}
+	}
+
+	return strings.Join(names, "|")
+}
+
+// Event describes the operations performed on a single file.
+type Event struct {
+	// Path is the path of the file. It is the new path of
+	// a renamed file.
+	Path string
+
+	// Op is the operations that were performed on the file.
+	Op Op
+
+	// Old is the information about the file from the previous scan,
+	// or from before the file was renamed. It is nil if the Watcher
+	// did not know about the file before (e.g., if it was created).
+	Old *MatchInfo
+
+	// New is the information about the file from the latest scan.
+	// It is nil if the file was deleted.
+	New *MatchInfo
+
+	// Criterion is the criterion that the file matched
+	// (see MatchInfo.MatchedOn).
+	Criterion string
+
+	// Time is the time that the scan that found the operations started.
+	Time time.Time
+}
+
+func (o *defaultChange) Events() []Event {
+	pathsToEvents := make(map[string]*Event)
+
+	add := func(filePath string, op Op, oldInfo *MatchInfo, newInfo *MatchInfo) {
+		event, exists := pathsToEvents[filePath]
+		if !exists {
+			event = &Event{
+				Path: filePath,
+				Time: o.time,
+			}
+			pathsToEvents[filePath] = event
+		}
+
+		event.Op |= op
+
+		if event.Old == nil && oldInfo != nil {
+			info := *oldInfo
+			event.Old = &info
+		}
+
+		if newInfo != nil {
+			info := *newInfo
+			event.New = &info
+		}
+	}
+
+	for _, r := range o.renames {
+		r := r
+		add(r.new.Path, OpRename, &r.old, &r.new)
+	}
+
+	for _, state := range []changeState{created, existing, updated, attributeChanged, deleted} {
+		for _, info := range o.stateToInfo[state] {
+			info := info
+			switch state {
+			case created, existing:
+				add(info.Path, OpCreate, nil, &info)
+			case updated:
+				previous := o.previousInfo(info.Path)
+				if previous == nil {
+					// The file was found by the Watcher's first scan.
+					add(info.Path, OpCreate, nil, &info)
+					continue
+				}
+
+				add(info.Path, OpWrite, previous, &info)
+			case attributeChanged:
+				add(info.Path, OpChmod, o.previousInfo(info.Path), &info)
+			case deleted:
+				add(info.Path, OpRemove, &info, nil)
+			}
+		}
+	}
+
+	var events []Event
+
+	for _, event := range pathsToEvents {
+		if event.New != nil {
+			event.Criterion = event.New.MatchedOn
+		} else if event.Old != nil {
+			event.Criterion = event.Old.MatchedOn
+		}
+
+		events = append(events, *event)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Path < events[j].Path
+	})
+
+	return events
+}
+
+// previousInfo returns the information about a file from the scan that
+// preceded the Change, or nil if the file was not found by that scan.
+func (o *defaultChange) previousInfo(filePath string) *MatchInfo {
+	info, exists := o.previous[filePath]
+	if !exists {
+		return nil
+	}
+
+	return &info
+}
+
+// sendEvents sends the Change's Events to the Config's Events channel,
+// if it is specified.
+func (o Config) sendEvents(change *defaultChange) {
+	if o.Events == nil {
+		return
+	}
+
+	for _, event := range change.Events() {
+		o.Events <- event
+	}
+}
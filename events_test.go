@@ -0,0 +1,159 @@
+package watcher
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestOp(t *testing.T) {
+	op := OpWrite | OpChmod
+
+	if !op.Has(OpWrite) || !op.Has(OpChmod) || op.Has(OpCreate) {
+		t.Fatal("Op does not have expected operations -", op)
+	}
+
+	if op.String() != "WRITE|CHMOD" {
+		t.Fatal("Got unexpected string -", op.String())
+	}
+}
+
+func TestDefaultChange_Events(t *testing.T) {
+	dirPath := t.TempDir()
+	modified := path.Join(dirPath, "modified.txt")
+	writeTestFile(t, modified, "hello")
+	deleted := path.Join(dirPath, "deleted.txt")
+	writeTestFile(t, deleted, "hello")
+	renamed := path.Join(dirPath, "renamed.txt")
+	writeTestFile(t, renamed, "hello")
+
+	w := newDefaultWatcher(Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{searchFileExt},
+		ScanFunc:     ScanFilesInDirectory,
+	})
+
+	events := w.scan(w.config).Events()
+	if len(events) != 3 || events[0].Op != OpCreate || events[0].Old != nil || events[0].New == nil {
+		t.Fatal("Did not get expected events for the first scan -", events)
+	}
+
+	created := path.Join(dirPath, "created.txt")
+	writeTestFile(t, created, "created")
+
+	writeTestFile(t, modified, "goodbye")
+	err := os.Chmod(modified, 0644)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = os.Remove(deleted)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	moved := path.Join(dirPath, "moved.txt")
+	err = os.Rename(renamed, moved)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	before := time.Now()
+	events = w.scan(w.config).Events()
+
+	exp := []struct {
+		path    string
+		op      Op
+		old     bool
+		new     bool
+		oldPath string
+	}{
+		{path: created, op: OpCreate, new: true},
+		{path: deleted, op: OpRemove, old: true, oldPath: deleted},
+		{path: modified, op: OpWrite | OpChmod, old: true, new: true, oldPath: modified},
+		{path: moved, op: OpRename, old: true, new: true, oldPath: renamed},
+	}
+
+	if len(events) != len(exp) {
+		t.Fatal("Did not get expected events -", events)
+	}
+
+	for i := range exp {
+		event := events[i]
+
+		if event.Path != exp[i].path || event.Op != exp[i].op {
+			t.Fatal("Got unexpected event -", event.Path, event.Op)
+		}
+
+		if (event.Old != nil) != exp[i].old || (event.New != nil) != exp[i].new {
+			t.Fatal("Got unexpected MatchInfo for event -", event)
+		}
+
+		if event.Old != nil && event.Old.Path != exp[i].oldPath {
+			t.Fatal("Got unexpected old MatchInfo for event -", event.Old)
+		}
+
+		if event.Criterion != searchFileExt {
+			t.Fatal("Got unexpected criterion -", event.Criterion)
+		}
+
+		if event.Time.Before(before) {
+			t.Fatal("Got unexpected time -", event.Time)
+		}
+	}
+
+	if events[2].Old.Mode == events[2].New.Mode {
+		t.Fatal("Old and new MatchInfo are the same")
+	}
+}
+
+func TestDefaultWatcher_Events(t *testing.T) {
+	dirPath := t.TempDir()
+	filePath := path.Join(dirPath, "file.txt")
+	writeTestFile(t, filePath, "hello")
+
+	config := Config{
+		RefreshDelay: 50 * time.Millisecond,
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{searchFileExt},
+		Events:       make(chan Event),
+		ScanFunc:     ScanFilesInDirectory,
+		Backend:      PollingBackend,
+	}
+	_, err := NewWatcher(config)
+	if err == nil {
+		t.Fatal("Config without a changes channel did not generate an error")
+	}
+
+	config.Changes = make(chan Change)
+	w, err := NewWatcher(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	w.Start()
+
+	receiveChange(t, config.Changes)
+
+	select {
+	case event := <-config.Events:
+		if event.Path != filePath || event.Op != OpCreate {
+			t.Fatal("Got unexpected event -", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+
+	w.Destroy()
+
+	select {
+	case _, ok := <-config.Events:
+		if !ok {
+			return
+		}
+	case <-time.After(time.Second):
+	}
+
+	t.Fatal("Events channel is still open after destroy")
+}
@@ -0,0 +1,118 @@
+package watcher
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// fileSystem is the file system that the scan functions read from.
+type fileSystem interface {
+	// readDir returns the directory's entries sorted by name.
+	readDir(dirPath string) ([]os.FileInfo, error)
+
+	// stat returns information about a file, following symlinks.
+	stat(filePath string) (os.FileInfo, error)
+
+	// lstat returns information about a file without following
+	// symlinks, if the file system supports symlinks.
+	lstat(filePath string) (os.FileInfo, error)
+
+	// readFile returns the contents of a file.
+	readFile(filePath string) ([]byte, error)
+
+	// open opens a file for reading.
+	open(filePath string) (fs.File, error)
+
+	// evalSymlinks returns the resolved path of a symlink.
+	evalSymlinks(filePath string) (string, error)
+}
+
+// fileSystem returns the file system that the Config's paths refer to.
+func (o Config) fileSystem() fileSystem {
+	if o.FS != nil {
+		return &fsFileSystem{
+			fsys: o.FS,
+		}
+	}
+
+	return &osFileSystem{}
+}
+
+type osFileSystem struct{}
+
+func (o *osFileSystem) readDir(dirPath string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(dirPath)
+}
+
+func (o *osFileSystem) stat(filePath string) (os.FileInfo, error) {
+	return os.Stat(filePath)
+}
+
+func (o *osFileSystem) lstat(filePath string) (os.FileInfo, error) {
+	return os.Lstat(filePath)
+}
+
+func (o *osFileSystem) readFile(filePath string) ([]byte, error) {
+	return ioutil.ReadFile(filePath)
+}
+
+func (o *osFileSystem) open(filePath string) (fs.File, error) {
+	return os.Open(filePath)
+}
+
+func (o *osFileSystem) evalSymlinks(filePath string) (string, error) {
+	return filepath.EvalSymlinks(filePath)
+}
+
+// fsFileSystem reads from an fs.FS. Because fs.FS does not provide
+// a means of reading symlinks, lstat is the same as stat (unless
+// a symlink is reported by readDir), and symlinks cannot be resolved.
+type fsFileSystem struct {
+	fsys fs.FS
+}
+
+func (o *fsFileSystem) readDir(dirPath string) ([]os.FileInfo, error) {
+	entries, err := fs.ReadDir(o.fsys, dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]os.FileInfo, 0, len(entries))
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+func (o *fsFileSystem) stat(filePath string) (os.FileInfo, error) {
+	return fs.Stat(o.fsys, filePath)
+}
+
+func (o *fsFileSystem) lstat(filePath string) (os.FileInfo, error) {
+	return fs.Stat(o.fsys, filePath)
+}
+
+func (o *fsFileSystem) readFile(filePath string) ([]byte, error) {
+	return fs.ReadFile(o.fsys, filePath)
+}
+
+func (o *fsFileSystem) open(filePath string) (fs.File, error) {
+	return o.fsys.Open(filePath)
+}
+
+func (o *fsFileSystem) evalSymlinks(filePath string) (string, error) {
+	return "", &fs.PathError{
+		Op:   "evalsymlinks",
+		Path: filePath,
+		Err:  fs.ErrInvalid,
+	}
+}
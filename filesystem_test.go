@@ -0,0 +1,134 @@
+package watcher
+
+import (
+	"os"
+	"path"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestScanFS(t *testing.T) {
+	config := Config{
+		RootDirPath:  testDataDirPath(),
+		ScanCriteria: []string{searchFileExt},
+	}
+
+	osResult, err := ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	config.RootDirPath = "."
+	fsResult, err := ScanFS(os.DirFS(testDataDirPath()), ScanFilesRecursively)(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(osResult.FilePathsToInfo) != len(fsResult.FilePathsToInfo) {
+		t.Fatal("Got different number of results -", len(osResult.FilePathsToInfo), len(fsResult.FilePathsToInfo))
+	}
+
+	for osFilePath, osInfo := range osResult.FilePathsToInfo {
+		fsFilePath := osFilePath[len(testDataDirPath())+1:]
+
+		fsInfo, ok := fsResult.FilePathsToInfo[fsFilePath]
+		if !ok {
+			t.Fatal("File is missing from fs.FS result -", fsFilePath)
+		}
+
+		if fsInfo.Path != fsFilePath || fsInfo.ModTime != osInfo.ModTime || fsInfo.MatchedOn != osInfo.MatchedOn {
+			t.Fatal("Got different MatchInfo -", osInfo, fsInfo)
+		}
+	}
+}
+
+func TestScanFS_Notified(t *testing.T) {
+	scanFunc := ScanFS(fstest.MapFS{}, ScanFilesInDirectory)
+
+	_, err := scanFunc(Config{RootDirPath: ".", notified: true})
+	if err == nil {
+		t.Fatal("ScanFS used by a notified Watcher did not generate an error")
+	}
+
+	_, err = scanFunc(Config{RootDirPath: "."})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func TestConfigFS_MapFS(t *testing.T) {
+	modTime := time.Unix(1000, 0)
+
+	fsys := fstest.MapFS{
+		"app/config.yaml":        {ModTime: modTime},
+		"app/.gitignore":         {Data: []byte("ignored.yaml\n")},
+		"app/ignored.yaml":       {ModTime: modTime},
+		"app/plugins/extra.yaml": {ModTime: modTime},
+		"app/readme.md":          {ModTime: modTime},
+		"other.yaml":             {ModTime: modTime},
+	}
+
+	config := Config{
+		FS:             fsys,
+		RootDirPath:    "app",
+		ScanCriteria:   []string{".yaml"},
+		UseIgnoreFiles: true,
+	}
+
+	result, err := ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"config.yaml",
+		"extra.yaml",
+	})
+
+	info := result.FilePathsToInfo[path.Join("app", "plugins", "extra.yaml")]
+	if info.ModTime != modTime || info.Root != "app" {
+		t.Fatal("Got unexpected MatchInfo -", info)
+	}
+
+	config.FilePaths = []string{"other.yaml", "missing.yaml"}
+	result, err = ScanFilePaths(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"other.yaml",
+	})
+}
+
+func TestNewWatcher_FS(t *testing.T) {
+	config := Config{
+		FS:           fstest.MapFS{},
+		RootDirPath:  "/app",
+		ScanCriteria: []string{".yaml"},
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesInDirectory,
+	}
+
+	_, err := NewWatcher(config)
+	if err == nil {
+		t.Fatal("Invalid fs.FS path did not generate an error")
+	}
+
+	config.RootDirPath = "app"
+	w, err := NewWatcher(config)
+	if err != nil {
+		t.Fatal("Valid config generated an error -", err.Error())
+	}
+
+	if w.Backend() != PollingBackend {
+		t.Fatal("Got unexpected backend -", w.Backend())
+	}
+
+	config.Backend = NotifyBackend
+	_, err = NewWatcher(config)
+	if err == nil {
+		t.Fatal("Notify backend with an fs.FS did not generate an error")
+	}
+}
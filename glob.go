@@ -0,0 +1,246 @@
+package watcher
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+// matchesGlobs matches a file against a slice of glob patterns.
+// A pattern that does not contain a '/' is matched against the file's
+// name. Otherwise, it is matched against the file's path relative to
+// the root directory.
+//
+// Patterns are evaluated in order, and the last pattern that matches
+// the file wins. A pattern beginning with '!' excludes files that were
+// matched by a previous pattern. The returned string is the pattern that
+// matched the file.
+func matchesGlobs(name string, relPath string, patterns []string) (string, bool) {
+	var matchedOn string
+
+	for _, pattern := range patterns {
+		glob, negate := parseGlobNegation(pattern)
+
+		if !globMatches(glob, name, relPath) {
+			continue
+		}
+
+		if negate {
+			matchedOn = ""
+		} else {
+			matchedOn = pattern
+		}
+	}
+
+	return matchedOn, len(matchedOn) > 0
+}
+
+// globMatches returns true if a glob pattern (without a leading '!')
+// matches a file. Errors are ignored because patterns are validated
+// by validateGlob.
+func globMatches(glob string, name string, relPath string) bool {
+	alternatives, err := expandBraces(glob)
+	if err != nil {
+		return false
+	}
+
+	for _, alternative := range alternatives {
+		if !strings.Contains(alternative, "/") {
+			matches, _ := path.Match(alternative, name)
+			if matches {
+				return true
+			}
+
+			continue
+		}
+
+		if alternativeMatchesPath(alternative, relPath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nameGlobMatches returns true if a glob pattern matches a file's name.
+func nameGlobMatches(glob string, name string) bool {
+	alternatives, err := expandBraces(glob)
+	if err != nil {
+		return false
+	}
+
+	for _, alternative := range alternatives {
+		matches, _ := path.Match(alternative, name)
+		if matches {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pathGlobMatches returns true if a glob pattern matches a file's path
+// relative to the root directory, regardless of whether the pattern
+// contains a '/'.
+func pathGlobMatches(glob string, relPath string) bool {
+	alternatives, err := expandBraces(glob)
+	if err != nil {
+		return false
+	}
+
+	for _, alternative := range alternatives {
+		if alternativeMatchesPath(alternative, relPath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func alternativeMatchesPath(alternative string, relPath string) bool {
+	alternative = strings.TrimPrefix(alternative, "/")
+
+	return matchGlobSegments(strings.Split(alternative, "/"), strings.Split(relPath, "/"))
+}
+
+// matchGlobSegments matches slash-separated glob segments against
+// slash-separated path segments. A "**" segment matches zero or more
+// path segments. A trailing "**" segment matches one or more path
+// segments.
+func matchGlobSegments(globSegments []string, pathSegments []string) bool {
+	for len(globSegments) > 0 {
+		if globSegments[0] == "**" {
+			for len(globSegments) > 1 && globSegments[1] == "**" {
+				globSegments = globSegments[1:]
+			}
+
+			if len(globSegments) == 1 {
+				return len(pathSegments) > 0
+			}
+
+			for i := range pathSegments {
+				if matchGlobSegments(globSegments[1:], pathSegments[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(pathSegments) == 0 {
+			return false
+		}
+
+		matches, _ := path.Match(globSegments[0], pathSegments[0])
+		if !matches {
+			return false
+		}
+
+		globSegments = globSegments[1:]
+		pathSegments = pathSegments[1:]
+	}
+
+	return len(pathSegments) == 0
+}
+
+// parseGlobNegation removes the leading '!' from a negated pattern.
+// A leading '\!' is unescaped to a literal '!'.
+func parseGlobNegation(pattern string) (string, bool) {
+	if strings.HasPrefix(pattern, "!") {
+		return pattern[1:], true
+	}
+
+	if strings.HasPrefix(pattern, `\!`) {
+		return pattern[1:], false
+	}
+
+	return pattern, false
+}
+
+// expandBraces expands brace alternations in a glob pattern.
+// For example, "*.{yaml,yml}" is expanded to "*.yaml" and "*.yml".
+// Braces may be nested. Braces inside of character classes, and braces
+// escaped with a '\', are treated literally.
+func expandBraces(pattern string) ([]string, error) {
+	start := -1
+	depth := 0
+	inClass := false
+	var commas []int
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+		case inClass:
+			if c == ']' {
+				inClass = false
+			}
+		case c == '[':
+			inClass = true
+		case c == '{':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case c == ',' && depth == 1:
+			commas = append(commas, i)
+		case c == '}':
+			if depth == 0 {
+				return nil, errors.New("unmatched '}' in pattern '" + pattern + "'")
+			}
+
+			depth--
+			if depth > 0 {
+				continue
+			}
+
+			prefix := pattern[:start]
+			suffix := pattern[i+1:]
+			bounds := append(append([]int{start}, commas...), i)
+
+			var expanded []string
+
+			for j := 0; j < len(bounds)-1; j++ {
+				alternatives, err := expandBraces(prefix + pattern[bounds[j]+1:bounds[j+1]] + suffix)
+				if err != nil {
+					return nil, err
+				}
+
+				expanded = append(expanded, alternatives...)
+			}
+
+			return expanded, nil
+		}
+	}
+
+	if depth > 0 {
+		return nil, errors.New("unmatched '{' in pattern '" + pattern + "'")
+	}
+
+	return []string{pattern}, nil
+}
+
+// validateGlob returns a non-nil error if the glob pattern is malformed.
+func validateGlob(pattern string) error {
+	glob, _ := parseGlobNegation(pattern)
+
+	if len(glob) == 0 {
+		return errors.New("glob pattern cannot be empty")
+	}
+
+	alternatives, err := expandBraces(glob)
+	if err != nil {
+		return err
+	}
+
+	for _, alternative := range alternatives {
+		for _, segment := range strings.Split(alternative, "/") {
+			_, err := path.Match(segment, "")
+			if err != nil {
+				return errors.New("malformed glob pattern '" + pattern + "' - " + err.Error())
+			}
+		}
+	}
+
+	return nil
+}
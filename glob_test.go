@@ -0,0 +1,85 @@
+package watcher
+
+import (
+	"path"
+	"testing"
+)
+
+func TestMatchesGlobs(t *testing.T) {
+	tests := []struct {
+		relPath   string
+		patterns  []string
+		matchedOn string
+	}{
+		{"a.txt", []string{"*.txt"}, "*.txt"},
+		{"dir/a.txt", []string{"*.txt"}, "*.txt"},
+		{"dir/a.txt", []string{"dir/*.txt"}, "dir/*.txt"},
+		{"dir/a.txt", []string{"/dir/*.txt"}, "/dir/*.txt"},
+		{"a.txt", []string{"dir/*.txt"}, ""},
+		{"configs/app.yaml", []string{"configs/**/*.yaml"}, "configs/**/*.yaml"},
+		{"configs/x/y/app.yaml", []string{"configs/**/*.yaml"}, "configs/**/*.yaml"},
+		{"other/app.yaml", []string{"configs/**/*.yaml"}, ""},
+		{"configs/x/app.yaml", []string{"configs/**"}, "configs/**"},
+		{"app.yml", []string{"*.{yaml,yml}"}, "*.{yaml,yml}"},
+		{"a/b/app.json", []string{"{a/**/,}*.{json,{ya,y}ml}"}, "{a/**/,}*.{json,{ya,y}ml}"},
+		{"app.toml", []string{"*.{yaml,yml}"}, ""},
+		{"file1.txt", []string{"file[0-9].txt"}, "file[0-9].txt"},
+		{"filea.txt", []string{"file[^0-9].txt"}, "file[^0-9].txt"},
+		{"file{.txt", []string{`file\{.txt`}, `file\{.txt`},
+		{"main.go", []string{"**/*.go", "!**/vendor/**"}, "**/*.go"},
+		{"vendor/x/main.go", []string{"**/*.go", "!**/vendor/**"}, ""},
+		{"vendor/x/main.go", []string{"**/*.go", "!**/vendor/**", "vendor/x/*"}, "vendor/x/*"},
+		{"!bang", []string{`\!bang`}, `\!bang`},
+	}
+
+	for _, test := range tests {
+		matchedOn, matches := matchesGlobs(path.Base(test.relPath), test.relPath, test.patterns)
+		if matchedOn != test.matchedOn || matches != (len(test.matchedOn) > 0) {
+			t.Fatal("Got unexpected match for", test.relPath, test.patterns, "-", matchedOn, matches)
+		}
+	}
+}
+
+func TestValidateGlob(t *testing.T) {
+	for _, pattern := range []string{"", "!", "a[", "{a,b", "a}", "x/{[}/y"} {
+		if validateGlob(pattern) == nil {
+			t.Fatal("Malformed pattern did not generate an error -", pattern)
+		}
+	}
+
+	for _, pattern := range []string{"*.txt", "!**/vendor/**", "{a,b}/[{]", `\{`} {
+		err := validateGlob(pattern)
+		if err != nil {
+			t.Fatal("Valid pattern generated an error -", pattern, err.Error())
+		}
+	}
+}
+
+func TestScanFilesRecursively_GlobCriteria(t *testing.T) {
+	config := Config{
+		RootDirPath:  testDataDirPath(),
+		ScanCriteria: []string{"subdir/**/*.txt", "!subdir/subsubdir/**"},
+		CriteriaType: GlobCriteria,
+	}
+
+	err := config.validateCriteria()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	result, err := ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"subdirfile1.txt",
+		"subdirfile2.txt",
+	})
+
+	for _, info := range result.FilePathsToInfo {
+		if info.MatchedOn != config.ScanCriteria[0] {
+			t.Fatal("Got unexpected MatchedOn -", info.MatchedOn)
+		}
+	}
+}
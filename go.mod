@@ -1 +1,3 @@
 module github.com/stephen-fox/watcher
+
+go 1.17
@@ -0,0 +1,150 @@
+package watcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"time"
+)
+
+// racyFiles returns the paths of the files in the ScanResult whose
+// modification time is too close to the start of the scan to be
+// trusted. Such a file may be written again without changing its
+// modification time, so its contents must be checked in the next scan.
+func (o Config) racyFiles(result ScanResult, scanTime time.Time) map[string]bool {
+	granularity := defaultModTimeGranularity
+	if o.ModTimeGranularity != 0 {
+		granularity = o.ModTimeGranularity
+	}
+
+	if granularity < 0 {
+		return nil
+	}
+
+	racy := make(map[string]bool)
+	threshold := scanTime.Add(-granularity)
+
+	for filePath, info := range result.FilePathsToInfo {
+		if info.ModTime.After(threshold) {
+			racy[filePath] = true
+		}
+	}
+
+	return racy
+}
+
+// hashContents sets the Hash of the files in the current ScanResult.
+// All files are hashed if HashContents is true. Otherwise, only files
+// that are racy in either the last or the current scan are hashed.
+//
+// The hash from the last ScanResult is reused if the file's metadata
+// has not changed and it was not racy. Files that already have a hash
+// (e.g., files inside of an archive) are not hashed again. A file that
+// cannot be read is left without a hash, in which case it is compared
+// by its metadata.
+func (o Config) hashContents(current ScanResult, last ScanResult, lastRacy map[string]bool, currentRacy map[string]bool) {
+	fsys := o.fileSystem()
+
+	for filePath, info := range current.FilePathsToInfo {
+		if len(info.Hash) > 0 {
+			continue
+		}
+
+		if !o.HashContents && !lastRacy[filePath] && !currentRacy[filePath] {
+			continue
+		}
+
+		previous, exists := last.FilePathsToInfo[filePath]
+		if exists && !lastRacy[filePath] && len(previous.Hash) > 0 && !metadataChanged(previous, info) {
+			info.Hash = previous.Hash
+			current.FilePathsToInfo[filePath] = info
+			continue
+		}
+
+		f, err := fsys.open(filePath)
+		if err != nil {
+			continue
+		}
+
+		info.Hash, err = o.hashReader(f)
+		f.Close()
+		if err != nil {
+			continue
+		}
+
+		current.FilePathsToInfo[filePath] = info
+	}
+}
+
+// hashReader returns the hex-encoded digest of the data read from r.
+func (o Config) hashReader(r io.Reader) (string, error) {
+	var h hash.Hash
+	if o.NewHash != nil {
+		h = o.NewHash()
+	} else {
+		h = sha256.New()
+	}
+
+	_, err := io.Copy(h, r)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// modified returns true if a file's contents have changed since the
+// last scan. Files are compared by hash if HashContents is true and both
+// have a hash. Otherwise, they are compared by their metadata, and then
+// by hash if both have one (i.e., because the file was racy). A change
+// of the file's change time is ignored if its attributes changed, since
+// that explains the new change time.
+func (o Config) modified(last MatchInfo, current MatchInfo) bool {
+	hashed := len(last.Hash) > 0 && len(current.Hash) > 0
+
+	if o.HashContents && hashed {
+		return last.Hash != current.Hash
+	}
+
+	if !current.ModTime.Equal(last.ModTime) || current.Size != last.Size || current.Inode != last.Inode {
+		return true
+	}
+
+	if current.checksum != last.checksum {
+		return true
+	}
+
+	if !current.ChangeTime.Equal(last.ChangeTime) && !attributesChanged(last, current) {
+		return true
+	}
+
+	return hashed && last.Hash != current.Hash
+}
+
+// baselineModified returns true if a file's contents have changed since
+// the Config's Baseline. Unlike modified, it only compares the fields that
+// a Baseline is required to specify, and the file's hash.
+func (o Config) baselineModified(last MatchInfo, current MatchInfo) bool {
+	if o.HashContents && len(last.Hash) > 0 && len(current.Hash) > 0 {
+		return last.Hash != current.Hash
+	}
+
+	return !current.ModTime.Equal(last.ModTime) || current.Size != last.Size
+}
+
+// attributesChanged returns true if a file's mode, owner, or group differ.
+func attributesChanged(last MatchInfo, current MatchInfo) bool {
+	return current.Mode != last.Mode ||
+		current.Uid != last.Uid ||
+		current.Gid != last.Gid
+}
+
+// metadataChanged returns true if a file's modification time, size,
+// inode number, or change time differ.
+func metadataChanged(last MatchInfo, current MatchInfo) bool {
+	return !current.ModTime.Equal(last.ModTime) ||
+		current.Size != last.Size ||
+		current.Inode != last.Inode ||
+		!current.ChangeTime.Equal(last.ChangeTime)
+}
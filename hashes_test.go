@@ -0,0 +1,147 @@
+package watcher
+
+import (
+	"hash"
+	"hash/crc32"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestHashContents(t *testing.T) {
+	dirPath := t.TempDir()
+	filePath := path.Join(dirPath, "file.txt")
+	writeTestFile(t, filePath, "hello")
+
+	w := newDefaultWatcher(Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{searchFileExt},
+		ScanFunc:     ScanFilesInDirectory,
+		HashContents: true,
+	})
+
+	change := w.scan(w.config)
+	if len(change.UpdatedFilePaths()) != 1 {
+		t.Fatal("Did not get expected updated file paths -", change.UpdatedFilePaths())
+	}
+
+	// SHA-256 of "hello".
+	exp := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if hash := w.last.FilePathsToInfo[filePath].Hash; hash != exp {
+		t.Fatal("Got unexpected hash -", hash)
+	}
+
+	modTime := time.Now().Add(time.Hour).Truncate(time.Second)
+	err := os.Chtimes(filePath, modTime, modTime)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	change = w.scan(w.config)
+	if len(change.UpdatedFilePaths()) != 0 {
+		t.Fatal("Touching a file generated an update -", change.UpdatedFilePaths())
+	}
+
+	writeTestFile(t, filePath, "goodbye")
+	err = os.Chtimes(filePath, modTime, modTime)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	change = w.scan(w.config)
+	if len(change.UpdatedFilePaths()) != 1 {
+		t.Fatal("Modifying a file while preserving its mtime did not generate an update")
+	}
+}
+
+func TestHashContents_ReuseHash(t *testing.T) {
+	dirPath := t.TempDir()
+	filePath := path.Join(dirPath, "file.txt")
+	writeTestFile(t, filePath, "hello")
+
+	// Make sure that the file is not racy.
+	modTime := time.Now().Add(-time.Hour)
+	err := os.Chtimes(filePath, modTime, modTime)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var hashes int
+
+	w := newDefaultWatcher(Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{searchFileExt},
+		ScanFunc:     ScanFilesInDirectory,
+		HashContents: true,
+	})
+	w.config.NewHash = func() hash.Hash {
+		hashes++
+		return crc32.NewIEEE()
+	}
+
+	w.scan(w.config)
+	w.scan(w.config)
+
+	if hashes != 1 {
+		t.Fatal("Unchanged file was hashed", hashes, "times")
+	}
+
+	if len(w.last.FilePathsToInfo[filePath].Hash) != 8 {
+		t.Fatal("Custom hash was not used -", w.last.FilePathsToInfo[filePath].Hash)
+	}
+}
+
+func TestRacyFiles(t *testing.T) {
+	dirPath := t.TempDir()
+	filePath := path.Join(dirPath, "file.txt")
+	writeTestFile(t, filePath, "aaaaa")
+
+	// Simulate a file system with one second mtime resolution.
+	modTime := time.Now().Truncate(time.Second)
+	err := os.Chtimes(filePath, modTime, modTime)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	w := newDefaultWatcher(Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{searchFileExt},
+		// Simulate a file system without ctime.
+		ScanFunc: func(config Config) (ScanResult, error) {
+			result, err := ScanFilesInDirectory(config)
+
+			for filePath, info := range result.FilePathsToInfo {
+				info.ChangeTime = time.Time{}
+				result.FilePathsToInfo[filePath] = info
+			}
+
+			return result, err
+		},
+		ModTimeGranularity: time.Second,
+	})
+
+	w.scan(w.config)
+	if !w.racy[filePath] {
+		t.Fatal("File modified during the scan is not racy")
+	}
+
+	// Rewrite the file within the same second. The size, mtime, and
+	// inode stay the same.
+	writeTestFile(t, filePath, "bbbbb")
+	err = os.Chtimes(filePath, modTime, modTime)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	change := w.scan(w.config)
+	if len(change.UpdatedFilePaths()) != 1 {
+		t.Fatal("Rewrite of racy file was not detected")
+	}
+
+	w.config.ModTimeGranularity = -1
+	w.scan(w.config)
+	if len(w.racy) != 0 {
+		t.Fatal("Racy files were detected while disabled -", w.racy)
+	}
+}
@@ -0,0 +1,168 @@
+package watcher
+
+import (
+	"path"
+	"strings"
+)
+
+const (
+	defaultIgnoreFileName = ".gitignore"
+)
+
+// ignoreRule is a single pattern from an ignore file.
+type ignoreRule struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// ignoreFile contains the rules from an ignore file.
+type ignoreFile struct {
+	// relDirPath is the path of the directory containing the ignore
+	// file relative to the root directory.
+	relDirPath string
+	rules      []ignoreRule
+}
+
+// match matches a file or directory against the rules. The relPath is
+// relative to the root directory. The last rule that matches wins.
+// The second return value is false if no rule matched.
+func (o ignoreFile) match(relPath string, isDir bool) (bool, bool) {
+	if len(o.relDirPath) > 0 {
+		if !strings.HasPrefix(relPath, o.relDirPath+"/") {
+			return false, false
+		}
+
+		relPath = relPath[len(o.relDirPath)+1:]
+	}
+
+	for i := len(o.rules) - 1; i >= 0; i-- {
+		rule := o.rules[i]
+
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		var matches bool
+		if rule.anchored {
+			matches = matchGlobSegments(rule.segments, strings.Split(relPath, "/"))
+		} else {
+			matches, _ = path.Match(rule.segments[0], path.Base(relPath))
+		}
+
+		if matches {
+			return !rule.negate, true
+		}
+	}
+
+	return false, false
+}
+
+// isIgnored returns true if a file or directory is excluded by a stack of
+// ignore files ordered from the root directory downward. Rules in deeper
+// ignore files take precedence over rules in shallower ones.
+func isIgnored(ignores []ignoreFile, relPath string, isDir bool) bool {
+	for i := len(ignores) - 1; i >= 0; i-- {
+		ignored, matched := ignores[i].match(relPath, isDir)
+		if matched {
+			return ignored
+		}
+	}
+
+	return false
+}
+
+// parseIgnoreFile parses the contents of an ignore file using the rules
+// described by gitignore(5). Malformed patterns are skipped.
+func parseIgnoreFile(relDirPath string, contents string) ignoreFile {
+	result := ignoreFile{
+		relDirPath: relDirPath,
+	}
+
+	for _, line := range strings.Split(contents, "\n") {
+		rule, ok := parseIgnoreRule(strings.TrimSuffix(line, "\r"))
+		if ok {
+			result.rules = append(result.rules, rule)
+		}
+	}
+
+	return result
+}
+
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	var rule ignoreRule
+
+	// Trailing spaces are ignored unless they are escaped.
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+
+	if len(line) == 0 || strings.HasPrefix(line, "#") {
+		return rule, false
+	}
+
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	if len(line) == 0 {
+		return rule, false
+	}
+
+	for _, segment := range strings.Split(line, "/") {
+		segment = fnmatchToGlob(segment)
+
+		_, err := path.Match(segment, "")
+		if err != nil {
+			return rule, false
+		}
+
+		rule.segments = append(rule.segments, segment)
+	}
+
+	return rule, true
+}
+
+// fnmatchToGlob converts fnmatch(3) character class negations
+// (e.g., "[!a-z]") to the syntax supported by path.Match ("[^a-z]").
+func fnmatchToGlob(pattern string) string {
+	var b strings.Builder
+	inClass := false
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		b.WriteByte(c)
+
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteByte(pattern[i])
+		case inClass:
+			if c == ']' {
+				inClass = false
+			}
+		case c == '[':
+			inClass = true
+			if i+1 < len(pattern) && pattern[i+1] == '!' {
+				b.WriteByte('^')
+				i++
+			}
+		}
+	}
+
+	return b.String()
+}
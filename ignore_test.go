@@ -0,0 +1,116 @@
+package watcher
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestIsIgnored(t *testing.T) {
+	ignores := []ignoreFile{
+		parseIgnoreFile("", `
+# A comment.
+*.log
+!keep.log
+build/
+/top.txt
+docs/**/*.tmp
+\#literal
+trailing\ 
+file[!0-9].txt
+`),
+		parseIgnoreFile("sub", `!*.log`),
+	}
+
+	tests := []struct {
+		relPath string
+		isDir   bool
+		ignored bool
+	}{
+		{"a.log", false, true},
+		{"keep.log", false, false},
+		{"deep/er/a.log", false, true},
+		{"sub/a.log", false, false},
+		{"build", true, true},
+		{"sub/build", true, true},
+		{"build", false, false},
+		{"top.txt", false, true},
+		{"sub/top.txt", false, false},
+		{"docs/x/y/z.tmp", false, true},
+		{"docs/z.tmp", false, true},
+		{"other/z.tmp", false, false},
+		{"#literal", false, true},
+		{"# A comment.", false, false},
+		{"trailing ", false, true},
+		{"filea.txt", false, true},
+		{"file1.txt", false, false},
+	}
+
+	for _, test := range tests {
+		if isIgnored(ignores, test.relPath, test.isDir) != test.ignored {
+			t.Fatal("Got unexpected result for", test.relPath, "- expected", test.ignored)
+		}
+	}
+}
+
+func TestScanFilesRecursively_UseIgnoreFiles(t *testing.T) {
+	rootDirPath := tempDataDirPath(t)
+
+	writeTestFile(t, path.Join(rootDirPath, ".gitignore"), "file1.txt\nsubsubdir/\n")
+	writeTestFile(t, path.Join(rootDirPath, "subdir", ".gitignore"), "/subdirfile2.txt\n")
+
+	config := Config{
+		RootDirPath:    rootDirPath,
+		ScanCriteria:   []string{searchFileExt},
+		UseIgnoreFiles: true,
+	}
+
+	result, err := ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"file2.txt",
+		"subdirfile1.txt",
+	})
+
+	// Changes to ignore files apply to the next scan.
+	writeTestFile(t, path.Join(rootDirPath, "subdir", ".gitignore"), "")
+
+	result, err = ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"file2.txt",
+		"subdirfile1.txt",
+		"subdirfile2.txt",
+	})
+
+	config.IgnoreFileName = ".watcherignore"
+	writeTestFile(t, path.Join(rootDirPath, config.IgnoreFileName), "*.txt\n!file1.txt\n")
+
+	result, err = ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"file1.txt",
+	})
+}
+
+func writeTestFile(t *testing.T, filePath string, contents string) {
+	err := os.MkdirAll(path.Dir(filePath), 0700)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = ioutil.WriteFile(filePath, []byte(contents), 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
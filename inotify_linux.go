@@ -0,0 +1,438 @@
+//go:build linux
+// +build linux
+
+package watcher
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY |
+		syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_FROM |
+		syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+	// inotifySettleDelay is the time to wait for additional events
+	// before scanning. A single write usually produces several events.
+	inotifySettleDelay = 20 * time.Millisecond
+
+	inotifyBufferSize = 64 * (syscall.SizeofInotifyEvent + syscall.NAME_MAX + 1)
+)
+
+type inotifyEvent struct {
+	wd   int32
+	mask uint32
+	name string
+}
+
+type inotifyWatcher struct {
+	*defaultWatcher
+	file          *os.File
+	events        chan inotifyEvent
+	wdsToDirs     map[int32]string
+	dirsToWds     map[string]int32
+	lastReconcile time.Time
+}
+
+func (o *inotifyWatcher) Start() {
+	o.start(o.loop)
+}
+
+func (o *inotifyWatcher) Backend() Backend {
+	return NotifyBackend
+}
+
+func (o *inotifyWatcher) loop(config Config, stop chan struct{}) {
+	o.watchAll(config)
+	o.lastReconcile = time.Now()
+
+	// Like the polling Watcher, the first scan occurs after
+	// the RefreshDelay (unless a change occurs first).
+	reconcile, ok := o.wait(config, true, stop)
+
+	for ok {
+		if reconcile {
+			o.lastReconcile = time.Now()
+		}
+
+		change := o.scan(config)
+		change.reconciled = reconcile
+		if !o.report(config, change, stop) {
+			return
+		}
+
+		reconcile, ok = o.wait(config, false, stop)
+	}
+}
+
+// wait blocks until the kernel reports a change and the events settle,
+// until a reconciliation scan is due, until pending files may have
+// become stable (see Config.StableDelay), or until accumulated changes
+// are due (see Config.DebounceDelay). If this is the initial wait,
+// or the root directory is not being watched (e.g., because it does not
+// exist yet), wait also returns after the Config's RefreshDelay elapses.
+//
+// The first return value is true if the next scan is a reconciliation
+// scan. The second return value is false if the Watcher was stopped
+// or destroyed.
+func (o *inotifyWatcher) wait(config Config, initial bool, stop chan struct{}) (bool, bool) {
+	var reconcile <-chan time.Time
+	if !initial && config.ReconcileDelay > 0 {
+		reconcileTimer := time.NewTimer(time.Until(o.lastReconcile.Add(config.ReconcileDelay)))
+		defer reconcileTimer.Stop()
+		reconcile = reconcileTimer.C
+	}
+
+	var retry <-chan time.Time
+	watched := o.watchingAll(config)
+	if initial || !watched {
+		retryTimer := time.NewTimer(config.refreshDelay())
+		defer retryTimer.Stop()
+		retry = retryTimer.C
+	}
+
+	var stable <-chan time.Time
+	delay, pending := o.pendingDelay(config)
+	if pending {
+		stableTimer := time.NewTimer(delay)
+		defer stableTimer.Stop()
+		stable = stableTimer.C
+	}
+
+	var flush <-chan time.Time
+	delay, debouncing := o.debounceDelay(config)
+	if debouncing {
+		flushTimer := time.NewTimer(delay)
+		defer flushTimer.Stop()
+		flush = flushTimer.C
+	}
+
+	var settle <-chan time.Time
+	overflowed := false
+	events := o.events
+
+	for {
+		select {
+		case <-o.kill:
+			config.closeChannels()
+			return false, false
+		case <-stop:
+			return false, false
+		case event, open := <-events:
+			if !open {
+				events = nil
+				continue
+			}
+
+			if !o.handle(config, event) {
+				overflowed = true
+			}
+
+			if settle == nil {
+				settle = time.After(inotifySettleDelay)
+			}
+		case <-settle:
+			return overflowed, true
+		case <-reconcile:
+			return true, true
+		case <-stable:
+			return false, true
+		case <-flush:
+			return false, true
+		case <-retry:
+			if !watched {
+				o.watchAll(config)
+			}
+			return !initial, true
+		}
+	}
+}
+
+// handle keeps the watched directories in sync with the directory tree.
+// It returns false if the kernel's event queue overflowed, meaning that
+// events were lost.
+func (o *inotifyWatcher) handle(config Config, event inotifyEvent) bool {
+	if event.mask&syscall.IN_Q_OVERFLOW != 0 {
+		o.watchAll(config)
+		return false
+	}
+
+	if event.mask&syscall.IN_IGNORED != 0 {
+		dirPath, ok := o.wdsToDirs[event.wd]
+		if ok {
+			delete(o.wdsToDirs, event.wd)
+			if o.dirsToWds[dirPath] == event.wd {
+				delete(o.dirsToWds, dirPath)
+			}
+		}
+		return true
+	}
+
+	if len(event.name) == 0 {
+		return true
+	}
+
+	// Symlinks to directories must be watched if they are followed.
+	followable := event.mask&syscall.IN_ISDIR == 0 && config.Symlinks == FollowSymlinks
+	if event.mask&syscall.IN_ISDIR == 0 && !followable {
+		return true
+	}
+
+	parent, ok := o.wdsToDirs[event.wd]
+	if !ok {
+		return true
+	}
+
+	dirPath := path.Join(parent, event.name)
+
+	switch {
+	case event.mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+		if followable {
+			info, err := os.Stat(dirPath)
+			if err != nil || !info.IsDir() {
+				return true
+			}
+		}
+
+		o.watchTree(config, dirPath)
+	case event.mask&syscall.IN_MOVED_FROM != 0:
+		// The directory is watched again if it was moved to
+		// another watched directory (see IN_MOVED_TO).
+		o.unwatchTree(dirPath)
+	}
+
+	return true
+}
+
+// watchAll adds watches for the Config's RootDirPath, Roots, and all of
+// their subdirectories, as well as the directories containing its FilePaths.
+func (o *inotifyWatcher) watchAll(config Config) {
+	for _, root := range config.roots() {
+		o.watchTree(config, root.Path)
+	}
+
+	for _, dirPath := range config.fileDirPaths() {
+		o.addWatch(dirPath)
+	}
+}
+
+// watchingAll returns true if the directories added by watchAll
+// are being watched.
+func (o *inotifyWatcher) watchingAll(config Config) bool {
+	dirPaths := config.fileDirPaths()
+	for _, root := range config.roots() {
+		dirPaths = append(dirPaths, root.Path)
+	}
+
+	for _, dirPath := range dirPaths {
+		_, watched := o.dirsToWds[dirPath]
+		if !watched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// watchTree adds a watch for the specified directory and all of
+// its subdirectories.
+func (o *inotifyWatcher) watchTree(config Config, dirPath string) error {
+	return o.watchDir(config, dirPath, nil)
+}
+
+func (o *inotifyWatcher) watchDir(config Config, dirPath string, ancestors []os.FileInfo) error {
+	err := o.addWatch(dirPath)
+	if err != nil {
+		return err
+	}
+
+	dirInfo, err := os.Stat(dirPath)
+	if err != nil {
+		return err
+	}
+
+	ancestors = append(ancestors, dirInfo)
+
+	subInfos, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subInfos {
+		subPath := path.Join(dirPath, sub.Name())
+
+		info := sub
+		if sub.Mode()&os.ModeSymlink != 0 && config.Symlinks == FollowSymlinks {
+			info, err = os.Stat(subPath)
+			if err != nil {
+				continue
+			}
+		}
+
+		if !info.IsDir() || containsSameFile(ancestors, info) {
+			continue
+		}
+
+		o.watchDir(config, subPath, ancestors)
+	}
+
+	return nil
+}
+
+func (o *inotifyWatcher) addWatch(dirPath string) error {
+	rawConn, err := o.file.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var wd int
+	var addErr error
+
+	err = rawConn.Control(func(fd uintptr) {
+		wd, addErr = syscall.InotifyAddWatch(int(fd), dirPath, inotifyMask|syscall.IN_ONLYDIR)
+	})
+	if err != nil {
+		return err
+	}
+	if addErr != nil {
+		return os.NewSyscallError("inotify_add_watch", addErr)
+	}
+
+	o.wdsToDirs[int32(wd)] = dirPath
+	o.dirsToWds[dirPath] = int32(wd)
+
+	return nil
+}
+
+// unwatchTree removes the watches for the specified directory and all of
+// its subdirectories, such as when the directory is moved.
+func (o *inotifyWatcher) unwatchTree(dirPath string) {
+	for wd, watchedPath := range o.wdsToDirs {
+		if watchedPath != dirPath && !strings.HasPrefix(watchedPath, dirPath+"/") {
+			continue
+		}
+
+		o.removeWatch(wd)
+
+		delete(o.wdsToDirs, wd)
+		if o.dirsToWds[watchedPath] == wd {
+			delete(o.dirsToWds, watchedPath)
+		}
+	}
+}
+
+// removeWatch removes a watch. The error is ignored, since the kernel
+// removes the watch by itself if the directory was deleted.
+func (o *inotifyWatcher) removeWatch(wd int32) {
+	rawConn, err := o.file.SyscallConn()
+	if err != nil {
+		return
+	}
+
+	rawConn.Control(func(fd uintptr) {
+		syscall.InotifyRmWatch(int(fd), uint32(wd))
+	})
+}
+
+// read reads events from the inotify instance until it is closed.
+func (o *inotifyWatcher) read() {
+	defer close(o.events)
+
+	buffer := make([]byte, inotifyBufferSize)
+
+	for {
+		n, err := o.file.Read(buffer)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buffer[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(raw.Len)
+			if nameEnd > n {
+				break
+			}
+
+			event := inotifyEvent{
+				wd:   raw.Wd,
+				mask: raw.Mask,
+				name: strings.TrimRight(string(buffer[nameStart:nameEnd]), "\x00"),
+			}
+
+			select {
+			case o.events <- event:
+			case <-o.kill:
+				return
+			}
+
+			offset = nameEnd
+		}
+	}
+}
+
+func (o *inotifyWatcher) Destroy() {
+	o.defaultWatcher.Destroy()
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.file.Close()
+}
+
+// NewInotifyWatcher creates a new Watcher for the provided Config that
+// is notified of changes by Linux inotify rather than scanning on an
+// interval. Like the polling Watcher, the Config's ScanFunc is first
+// executed after the Config's RefreshDelay. After that, it is executed
+// shortly after a change occurs in the Config's RootDirPath or Roots
+// (or in any directory beneath them), or in a directory containing one
+// of the Config's FilePaths. As a result, the Watcher produces the same
+// Change values as the polling Watcher, without waiting for RefreshDelay.
+//
+// The RefreshDelay is also used to retry watching directories that
+// cannot be watched (e.g., because they do not exist yet). Set the
+// Config's ReconcileDelay to also scan periodically for changes that
+// inotify failed to report, such as when its event queue overflows.
+func NewInotifyWatcher(config Config) (Watcher, error) {
+	err := config.IsValid()
+	if err != nil {
+		return &inotifyWatcher{defaultWatcher: &defaultWatcher{}}, err
+	}
+
+	err = config.compileCriteria()
+	if err != nil {
+		return &inotifyWatcher{defaultWatcher: &defaultWatcher{}}, err
+	}
+
+	if config.FS != nil {
+		return &inotifyWatcher{defaultWatcher: &defaultWatcher{}},
+			errors.New("inotify cannot watch an fs.FS")
+	}
+
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return &inotifyWatcher{defaultWatcher: &defaultWatcher{}},
+			os.NewSyscallError("inotify_init1", err)
+	}
+
+	config.notified = true
+
+	w := &inotifyWatcher{
+		defaultWatcher: newDefaultWatcher(config),
+		file:           os.NewFile(uintptr(fd), "inotify"),
+		events:         make(chan inotifyEvent, 64),
+		wdsToDirs:      make(map[int32]string),
+		dirsToWds:      make(map[string]int32),
+	}
+
+	go w.read()
+
+	return w, nil
+}
@@ -0,0 +1,440 @@
+//go:build linux
+// +build linux
+
+package watcher
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestNewInotifyWatcher(t *testing.T) {
+	_, err := NewInotifyWatcher(Config{})
+	if err == nil {
+		t.Fatal("Empty config did not generate an error")
+	}
+
+	w, err := NewInotifyWatcher(Config{
+		RootDirPath:  "fdf",
+		ScanCriteria: []string{".bla"},
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesInDirectory,
+	})
+	if err != nil {
+		t.Fatal("Valid config generated an error -", err.Error())
+	}
+
+	w.Destroy()
+}
+
+func TestNewWatcher_NotifyBackend(t *testing.T) {
+	w, err := NewWatcher(Config{
+		RootDirPath:  testDataDirPath(),
+		ScanCriteria: []string{searchFileExt},
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesInDirectory,
+		Backend:      NotifyBackend,
+	})
+	if err != nil {
+		t.Fatal("Valid config generated an error -", err.Error())
+	}
+	defer w.Destroy()
+
+	if w.Backend() != NotifyBackend {
+		t.Fatal("Got unexpected backend -", w.Backend())
+	}
+}
+
+func TestInotifyWatcherScanFilesInDirectory_Start(t *testing.T) {
+	rootDirPath := tempDataDirPath(t)
+
+	config := Config{
+		RefreshDelay: 100 * time.Millisecond,
+		RootDirPath:  rootDirPath,
+		ScanCriteria: []string{searchFileExt},
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesInDirectory,
+	}
+	w, err := NewInotifyWatcher(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer w.Destroy()
+
+	w.Start()
+
+	change := receiveChange(t, config.Changes)
+	if len(change.UpdatedFilePaths()) != 2 {
+		t.Fatal("Did not get expected updated file paths -", change.UpdatedFilePaths())
+	}
+
+	newFilePath := path.Join(rootDirPath, "new.txt")
+	err = ioutil.WriteFile(newFilePath, []byte("hello"), 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	change = receiveChange(t, config.Changes)
+	if len(change.CreatedFilePaths()) != 1 || change.CreatedFilePaths()[0] != newFilePath {
+		t.Fatal("Did not get expected created file paths -", change.CreatedFilePaths())
+	}
+
+	err = os.Remove(newFilePath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	change = receiveChange(t, config.Changes)
+	if len(change.DeletedFilePaths()) != 1 || change.DeletedFilePaths()[0] != newFilePath {
+		t.Fatal("Did not get expected deleted file paths -", change.DeletedFilePaths())
+	}
+}
+
+func TestInotifyWatcherScanFilesInSubdirectories_NewSubdirectory(t *testing.T) {
+	rootDirPath := tempDataDirPath(t)
+
+	config := Config{
+		RefreshDelay: 100 * time.Millisecond,
+		RootDirPath:  rootDirPath,
+		ScanCriteria: []string{searchFileExt},
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesInSubdirectories,
+	}
+	w, err := NewInotifyWatcher(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer w.Destroy()
+
+	w.Start()
+
+	receiveChange(t, config.Changes)
+
+	newDirPath := path.Join(rootDirPath, "newdir")
+	err = os.Mkdir(newDirPath, 0700)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// Give the Watcher a chance to watch the new directory.
+	time.Sleep(100 * time.Millisecond)
+
+	newFilePath := path.Join(newDirPath, "new.txt")
+	err = ioutil.WriteFile(newFilePath, []byte("hello"), 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	change := receiveChange(t, config.Changes)
+	if len(change.CreatedFilePaths()) != 1 || change.CreatedFilePaths()[0] != newFilePath {
+		t.Fatal("Did not get expected created file paths -", change.CreatedFilePaths())
+	}
+}
+
+func TestInotifyWatcherScanFilesInDirectory_Destroy(t *testing.T) {
+	config := Config{
+		RefreshDelay: 100 * time.Millisecond,
+		RootDirPath:  tempDataDirPath(t),
+		ScanCriteria: []string{searchFileExt},
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesInDirectory,
+	}
+	w, err := NewInotifyWatcher(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	w.Start()
+
+	receiveChange(t, config.Changes)
+
+	w.Destroy()
+
+	select {
+	case _, ok := <-config.Changes:
+		if !ok {
+			return
+		}
+	case <-time.After(time.Second):
+	}
+
+	t.Fatal("Changes channel is still open after destroy")
+}
+
+func TestInotifyWatcher_Reconcile(t *testing.T) {
+	var scans int
+
+	config := Config{
+		RefreshDelay:   100 * time.Millisecond,
+		ReconcileDelay: 100 * time.Millisecond,
+		RootDirPath:    tempDataDirPath(t),
+		ScanCriteria:   []string{searchFileExt},
+		Changes:        make(chan Change),
+		ScanFunc: func(config Config) (ScanResult, error) {
+			scans++
+
+			// Simulate a change that inotify does not know about.
+			filePath := path.Join(config.RootDirPath, "virtual.txt")
+			result := ScanResult{
+				FilePathsToInfo: map[string]MatchInfo{
+					filePath: {
+						Path:      filePath,
+						ModTime:   time.Unix(int64(scans), 0),
+						MatchedOn: searchFileExt,
+					},
+				},
+			}
+
+			return result, nil
+		},
+	}
+	w, err := NewInotifyWatcher(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer w.Destroy()
+
+	w.Start()
+
+	change := receiveChange(t, config.Changes)
+	if change.FromReconciliation() {
+		t.Fatal("Initial change should not be from reconciliation")
+	}
+
+	change = receiveChange(t, config.Changes)
+	if !change.FromReconciliation() {
+		t.Fatal("Change should be from reconciliation")
+	}
+
+	if len(change.UpdatedFilePaths()) != 1 {
+		t.Fatal("Did not get expected updated file paths -", change.UpdatedFilePaths())
+	}
+}
+
+func TestInotifyWatcher_ScanFilePaths(t *testing.T) {
+	firstDirPath := t.TempDir()
+	secondDirPath := t.TempDir()
+
+	existing := path.Join(firstDirPath, "existing.cfg")
+	writeTestFile(t, existing, "hello")
+	missing := path.Join(secondDirPath, "missing.cfg")
+
+	config := Config{
+		RefreshDelay: 100 * time.Millisecond,
+		FilePaths:    []string{existing, missing},
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilePaths,
+	}
+	w, err := NewInotifyWatcher(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer w.Destroy()
+
+	w.Start()
+
+	change := receiveChange(t, config.Changes)
+	if len(change.UpdatedFilePaths()) != 1 || change.UpdatedFilePaths()[0] != existing {
+		t.Fatal("Did not get expected updated file paths -", change.UpdatedFilePaths())
+	}
+
+	writeTestFile(t, missing, "hello")
+
+	change = receiveChange(t, config.Changes)
+	if len(change.CreatedFilePaths()) != 1 || change.CreatedFilePaths()[0] != missing {
+		t.Fatal("Did not get expected created file paths -", change.CreatedFilePaths())
+	}
+}
+
+func TestInotifyWatcher_Rename(t *testing.T) {
+	rootDirPath := tempDataDirPath(t)
+
+	config := Config{
+		RefreshDelay: 100 * time.Millisecond,
+		RootDirPath:  rootDirPath,
+		ScanCriteria: []string{searchFileExt},
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesInSubdirectories,
+	}
+
+	otherDirPath := path.Join(rootDirPath, "other")
+	err := os.Mkdir(otherDirPath, 0700)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	w, err := NewInotifyWatcher(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer w.Destroy()
+
+	w.Start()
+
+	receiveChange(t, config.Changes)
+
+	oldPath := path.Join(rootDirPath, "subdir", "subdirfile1.txt")
+	newPath := path.Join(otherDirPath, "moved.txt")
+	err = os.Rename(oldPath, newPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	change := receiveChange(t, config.Changes)
+	renames := change.RenamedFilePaths()
+	if len(renames) != 1 || renames[0].OldPath != oldPath || renames[0].NewPath != newPath {
+		t.Fatal("Did not get expected renamed file paths -", renames)
+	}
+}
+
+func TestInotifyWatcher_StableDelay(t *testing.T) {
+	rootDirPath := tempDataDirPath(t)
+
+	config := Config{
+		RefreshDelay: 100 * time.Millisecond,
+		RootDirPath:  rootDirPath,
+		ScanCriteria: []string{searchFileExt},
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesInDirectory,
+		StableDelay:  300 * time.Millisecond,
+	}
+	w, err := NewInotifyWatcher(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer w.Destroy()
+
+	w.Start()
+
+	receiveChange(t, config.Changes)
+
+	newFilePath := path.Join(rootDirPath, "new.txt")
+	writeTestFile(t, newFilePath, "hello")
+	created := time.Now()
+
+	change := receiveChange(t, config.Changes)
+	if time.Since(created) < config.StableDelay {
+		t.Fatal("File was reported before it was stable")
+	}
+
+	if len(change.CreatedFilePaths()) != 1 || change.CreatedFilePaths()[0] != newFilePath {
+		t.Fatal("Did not get expected created file paths -", change.CreatedFilePaths())
+	}
+}
+
+func TestNewWatcher_AutoBackendScansPeriodically(t *testing.T) {
+	var scans int
+
+	config := Config{
+		RefreshDelay: 100 * time.Millisecond,
+		RootDirPath:  tempDataDirPath(t),
+		ScanCriteria: []string{searchFileExt},
+		Changes:      make(chan Change),
+		// Simulate a ScanFunc that finds changes outside of the
+		// root directory.
+		ScanFunc: func(config Config) (ScanResult, error) {
+			scans++
+
+			result := ScanResult{
+				FilePathsToInfo: map[string]MatchInfo{
+					"/elsewhere/file.txt": {
+						Path:      "/elsewhere/file.txt",
+						ModTime:   time.Unix(int64(scans), 0),
+						MatchedOn: searchFileExt,
+					},
+				},
+			}
+
+			return result, nil
+		},
+	}
+	w, err := NewWatcher(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer w.Destroy()
+
+	if w.Backend() != NotifyBackend {
+		t.Skip("The notify backend is not available for", config.RootDirPath)
+	}
+
+	if w.Config().ReconcileDelay != config.RefreshDelay {
+		t.Fatal("Got unexpected ReconcileDelay -", w.Config().ReconcileDelay)
+	}
+
+	w.Start()
+
+	receiveChange(t, config.Changes)
+
+	change := receiveChange(t, config.Changes)
+	if len(change.UpdatedFilePaths()) != 1 {
+		t.Fatal("Did not get expected updated file paths -", change.UpdatedFilePaths())
+	}
+}
+
+func TestInotifyWatcher_MovedDirectory(t *testing.T) {
+	rootDirPath := tempDataDirPath(t)
+	movedDirPath := path.Join(t.TempDir(), "moved")
+
+	config := Config{
+		RootDirPath:  rootDirPath,
+		ScanCriteria: []string{searchFileExt},
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesRecursively,
+	}
+
+	nested := path.Join(rootDirPath, "subdir", "nested")
+	err := os.Mkdir(nested, 0700)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	watcher, err := NewInotifyWatcher(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer watcher.Destroy()
+
+	w := watcher.(*inotifyWatcher)
+	w.watchAll(config)
+
+	err = os.Rename(path.Join(rootDirPath, "subdir"), movedDirPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// Handle the events the way that the Watcher's loop would.
+	handleEvents := func() {
+		for {
+			select {
+			case event := <-w.events:
+				w.handle(config, event)
+			case <-time.After(100 * time.Millisecond):
+				return
+			}
+		}
+	}
+
+	handleEvents()
+
+	for wd, dirPath := range w.wdsToDirs {
+		if dirPath != rootDirPath {
+			t.Fatal("Moved directory is still watched -", wd, dirPath)
+		}
+	}
+
+	if len(w.dirsToWds) != 1 {
+		t.Fatal("Got unexpected watched directories -", w.dirsToWds)
+	}
+
+	writeTestFile(t, path.Join(movedDirPath, "nested", "file.txt"), "hello")
+
+	select {
+	case event := <-w.events:
+		t.Fatal("Got event for moved directory -", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
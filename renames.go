@@ -0,0 +1,170 @@
+package watcher
+
+import (
+	"sort"
+)
+
+// Rename describes a file that was renamed or moved between two scans.
+//
+// A deleted file and a created file are considered to be the same file
+// if they have the same device and inode numbers, size, and modification
+// time. If the device and inode numbers are not available (e.g., when
+// scanning an fs.FS) or do not match (e.g., when a file is moved to
+// another device), they are considered to be the same file if their
+// contents have the same hash (see Config.HashContents). A renamed file
+// is not reported as created or deleted.
+type Rename struct {
+	// OldPath is the path of the file before it was renamed.
+	OldPath string
+
+	// NewPath is the path of the file after it was renamed.
+	NewPath string
+}
+
+type rename struct {
+	old MatchInfo
+	new MatchInfo
+}
+
+func (o *defaultChange) RenamedFilePaths() []Rename {
+	var r []Rename
+
+	for _, c := range o.renames {
+		r = append(r, c.toRename())
+	}
+
+	return r
+}
+
+func (o *defaultChange) RenamedFilePathsWithSuffixes(suffixes []string) []Rename {
+	var r []Rename
+
+	for _, c := range o.renames {
+		for i := range suffixes {
+			if c.new.MatchedOn == suffixes[i] {
+				r = append(r, c.toRename())
+				break
+			}
+		}
+	}
+
+	return r
+}
+
+func (o *defaultChange) RenamedFilePathsWithoutSuffixes(suffixes []string) []Rename {
+	var r []Rename
+
+OUTER:
+	for _, c := range o.renames {
+		for i := range suffixes {
+			if c.new.MatchedOn == suffixes[i] {
+				continue OUTER
+			}
+		}
+		r = append(r, c.toRename())
+	}
+
+	return r
+}
+
+func (o rename) toRename() Rename {
+	return Rename{
+		OldPath: o.old.Path,
+		NewPath: o.new.Path,
+	}
+}
+
+// pairRenames moves deleted and created files that are the same file
+// into the Change's renames. Files are paired in order of their paths
+// so that the result does not depend on map iteration order. Files with
+// the same inode are paired before files that are only the same by hash.
+func (o *defaultChange) pairRenames() {
+	deletedInfos := o.stateToInfo[deleted]
+	createdInfos := o.stateToInfo[created]
+	if len(deletedInfos) == 0 || len(createdInfos) == 0 {
+		return
+	}
+
+	sort.Slice(deletedInfos, func(i, j int) bool {
+		return deletedInfos[i].Path < deletedInfos[j].Path
+	})
+	sort.Slice(createdInfos, func(i, j int) bool {
+		return createdInfos[i].Path < createdInfos[j].Path
+	})
+
+	oldPaired := make(map[int]bool)
+	newPaired := make(map[int]bool)
+
+	for _, same := range []func(oldInfo MatchInfo, newInfo MatchInfo) bool{sameInode, sameHash} {
+		for i, oldInfo := range deletedInfos {
+			if oldPaired[i] {
+				continue
+			}
+
+			for j, newInfo := range createdInfos {
+				if newPaired[j] || !same(oldInfo, newInfo) {
+					continue
+				}
+
+				oldPaired[i] = true
+				newPaired[j] = true
+				o.renames = append(o.renames, rename{
+					old: oldInfo,
+					new: newInfo,
+				})
+				break
+			}
+		}
+	}
+
+	var unpaired []MatchInfo
+	for i, oldInfo := range deletedInfos {
+		if !oldPaired[i] {
+			unpaired = append(unpaired, oldInfo)
+		}
+	}
+
+	o.setState(deleted, unpaired)
+
+	unpaired = nil
+	for i, newInfo := range createdInfos {
+		if !newPaired[i] {
+			unpaired = append(unpaired, newInfo)
+		}
+	}
+
+	o.setState(created, unpaired)
+}
+
+// setState replaces the files in a state. The state is removed if
+// there are no files so that hasChanges remains accurate.
+func (o *defaultChange) setState(state changeState, infos []MatchInfo) {
+	if len(infos) == 0 {
+		delete(o.stateToInfo, state)
+		return
+	}
+
+	o.stateToInfo[state] = infos
+}
+
+// sameInode returns true if a deleted file and a created file have
+// the same inode, and therefore are the same file.
+func sameInode(oldInfo MatchInfo, newInfo MatchInfo) bool {
+	return oldInfo.Inode != 0 && newInfo.Inode != 0 &&
+		oldInfo.Device == newInfo.Device &&
+		oldInfo.Inode == newInfo.Inode &&
+		oldInfo.Size == newInfo.Size &&
+		oldInfo.ModTime.Equal(newInfo.ModTime)
+}
+
+// sameHash returns true if a deleted file and a created file have
+// the same contents. It is used when the inodes of the files are not
+// available or do not match (e.g., because the file was moved to
+// another device). Empty files are never the same by hash, since any
+// two of them have the same contents.
+func sameHash(oldInfo MatchInfo, newInfo MatchInfo) bool {
+	return len(oldInfo.Hash) > 0 &&
+		oldInfo.Hash == newInfo.Hash &&
+		oldInfo.Size > 0 &&
+		oldInfo.Size == newInfo.Size
+}
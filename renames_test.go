@@ -0,0 +1,165 @@
+package watcher
+
+import (
+	"os"
+	"path"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestDefaultWatcher_Renames(t *testing.T) {
+	dirPath := t.TempDir()
+	oldPath := path.Join(dirPath, "first", "file.txt")
+	writeTestFile(t, oldPath, "hello")
+	unrelated := path.Join(dirPath, "first", "unrelated.txt")
+	writeTestFile(t, unrelated, "hello")
+
+	w := newDefaultWatcher(Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{searchFileExt, ".cfg"},
+		ScanFunc:     ScanFilesInSubdirectories,
+	})
+
+	w.scan(w.config)
+
+	newPath := path.Join(dirPath, "second", "renamed.cfg")
+	err := os.MkdirAll(path.Dir(newPath), 0700)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = os.Rename(oldPath, newPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = os.Remove(unrelated)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	created := path.Join(dirPath, "second", "created.txt")
+	writeTestFile(t, created, "goodbye")
+
+	change := w.scan(w.config)
+
+	renames := change.RenamedFilePaths()
+	if len(renames) != 1 || renames[0].OldPath != oldPath || renames[0].NewPath != newPath {
+		t.Fatal("Did not get expected renamed file paths -", renames)
+	}
+
+	if len(change.CreatedFilePaths()) != 1 || change.CreatedFilePaths()[0] != created {
+		t.Fatal("Did not get expected created file paths -", change.CreatedFilePaths())
+	}
+
+	if len(change.DeletedFilePaths()) != 1 || change.DeletedFilePaths()[0] != unrelated {
+		t.Fatal("Did not get expected deleted file paths -", change.DeletedFilePaths())
+	}
+
+	if len(change.RenamedFilePathsWithSuffixes([]string{".cfg"})) != 1 {
+		t.Fatal("Did not get expected renamed file paths with suffixes")
+	}
+
+	if len(change.RenamedFilePathsWithoutSuffixes([]string{".cfg"})) != 0 {
+		t.Fatal("Did not get expected renamed file paths without suffixes")
+	}
+}
+
+func TestDefaultWatcher_RenamesByHash(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour)
+	fsys := fstest.MapFS{
+		"dir/old.txt":   {Data: []byte("hello"), ModTime: modTime},
+		"dir/other.txt": {Data: []byte("other"), ModTime: modTime},
+	}
+
+	w := newDefaultWatcher(Config{
+		RootDirPath:  "dir",
+		ScanCriteria: []string{searchFileExt},
+		ScanFunc:     ScanFilesInDirectory,
+		FS:           fsys,
+		HashContents: true,
+	})
+
+	w.scan(w.config)
+
+	fsys["dir/new.txt"] = fsys["dir/old.txt"]
+	delete(fsys, "dir/old.txt")
+	delete(fsys, "dir/other.txt")
+
+	change := w.scan(w.config)
+
+	renames := change.RenamedFilePaths()
+	if len(renames) != 1 || renames[0].OldPath != "dir/old.txt" || renames[0].NewPath != "dir/new.txt" {
+		t.Fatal("Did not get expected renamed file paths -", renames)
+	}
+
+	if len(change.CreatedFilePaths()) != 0 {
+		t.Fatal("Renamed file was reported as created -", change.CreatedFilePaths())
+	}
+
+	if len(change.DeletedFilePaths()) != 1 || change.DeletedFilePaths()[0] != "dir/other.txt" {
+		t.Fatal("Did not get expected deleted file paths -", change.DeletedFilePaths())
+	}
+}
+
+func TestDefaultChange_pairRenamesAcrossDevices(t *testing.T) {
+	modTime := time.Now()
+
+	change := &defaultChange{
+		stateToInfo: map[changeState][]MatchInfo{
+			deleted: {
+				{Path: "/a/moved.txt", Device: 1, Inode: 10, ModTime: modTime, Size: 5, Hash: "aaaa"},
+				{Path: "/a/renamed.txt", Device: 1, Inode: 11, ModTime: modTime, Size: 5, Hash: "aaaa"},
+			},
+			created: {
+				{Path: "/a/other.txt", Device: 1, Inode: 11, ModTime: modTime, Size: 5, Hash: "aaaa"},
+				{Path: "/b/moved.txt", Device: 2, Inode: 20, ModTime: modTime, Size: 5, Hash: "aaaa"},
+			},
+		},
+	}
+
+	change.pairRenames()
+
+	renames := change.RenamedFilePaths()
+	if len(renames) != 2 {
+		t.Fatal("Did not get expected renamed file paths -", renames)
+	}
+
+	if renames[0].OldPath != "/a/renamed.txt" || renames[0].NewPath != "/a/other.txt" {
+		t.Fatal("File was not paired by inode first -", renames)
+	}
+
+	if renames[1].OldPath != "/a/moved.txt" || renames[1].NewPath != "/b/moved.txt" {
+		t.Fatal("File moved to another device was not paired by hash -", renames)
+	}
+
+	if len(change.CreatedFilePaths()) != 0 || len(change.DeletedFilePaths()) != 0 {
+		t.Fatal("Renamed files were reported as created or deleted")
+	}
+}
+
+func TestSameHash(t *testing.T) {
+	info := MatchInfo{Path: "a", Size: 5, Hash: "aaaa"}
+
+	if !sameHash(info, MatchInfo{Path: "b", Size: 5, Hash: "aaaa"}) {
+		t.Fatal("Files with the same contents are not the same")
+	}
+
+	different := []MatchInfo{
+		{Path: "b", Size: 5, Hash: "bbbb"},
+		{Path: "b", Size: 6, Hash: "aaaa"},
+		{Path: "b", Size: 5},
+	}
+
+	for _, other := range different {
+		if sameHash(info, other) {
+			t.Fatal("Different files are the same -", other)
+		}
+	}
+
+	empty := MatchInfo{Path: "a", Hash: "e3b0"}
+	if sameHash(empty, MatchInfo{Path: "b", Hash: "e3b0"}) {
+		t.Fatal("Empty files are the same")
+	}
+}
@@ -0,0 +1,162 @@
+package watcher
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strings"
+)
+
+// RuleAction is the action taken when a Rule matches a file or directory.
+type RuleAction string
+
+const (
+	// IncludeRule includes the file or directory in the scan.
+	// An included file must still match the Config's ScanCriteria.
+	IncludeRule RuleAction = "include"
+
+	// ExcludeRule excludes the file or directory from the scan.
+	// An excluded directory is not scanned.
+	ExcludeRule RuleAction = "exclude"
+)
+
+// FileType is a type of file that a Rule applies to.
+type FileType string
+
+const (
+	// AnyFileType matches any type of file.
+	AnyFileType FileType = ""
+
+	// RegularFileType matches regular files.
+	RegularFileType FileType = "file"
+
+	// DirectoryFileType matches directories.
+	DirectoryFileType FileType = "directory"
+
+	// SymlinkFileType matches symbolic links.
+	SymlinkFileType FileType = "symlink"
+)
+
+// Rule includes or excludes files and directories during a scan.
+// A Rule matches a file or directory if all of its non-empty conditions
+// match it.
+type Rule struct {
+	// Action is the action taken when the Rule matches.
+	Action RuleAction
+
+	// Name is a glob pattern that is matched against the name of
+	// the file or directory. See GlobCriteria for the pattern syntax.
+	// The pattern cannot be negated with a leading '!' (use the
+	// opposite Action instead).
+	Name string
+
+	// Path is a glob pattern that is matched against the path of the
+	// file or directory relative to the root directory. See GlobCriteria
+	// for the pattern syntax. Like Name, it cannot be negated.
+	Path string
+
+	// MinSize is the minimum size of the file in bytes. A Rule that
+	// specifies a size does not match directories.
+	MinSize int64
+
+	// MaxSize is the maximum size of the file in bytes. A Rule that
+	// specifies a size does not match directories.
+	MaxSize int64
+
+	// Type is the type of file that the Rule matches.
+	Type FileType
+}
+
+func (o Rule) matches(info os.FileInfo, relPath string) bool {
+	switch o.Type {
+	case AnyFileType:
+	case RegularFileType:
+		if !info.Mode().IsRegular() {
+			return false
+		}
+	case DirectoryFileType:
+		if !info.IsDir() {
+			return false
+		}
+	case SymlinkFileType:
+		if info.Mode()&os.ModeSymlink == 0 {
+			return false
+		}
+	}
+
+	if o.MinSize > 0 || o.MaxSize > 0 {
+		if info.IsDir() {
+			return false
+		}
+
+		if o.MinSize > 0 && info.Size() < o.MinSize {
+			return false
+		}
+
+		if o.MaxSize > 0 && info.Size() > o.MaxSize {
+			return false
+		}
+	}
+
+	if len(o.Name) > 0 && !nameGlobMatches(o.Name, path.Base(relPath)) {
+		return false
+	}
+
+	if len(o.Path) > 0 && !pathGlobMatches(o.Path, relPath) {
+		return false
+	}
+
+	return true
+}
+
+func (o Rule) validate() error {
+	switch o.Action {
+	case IncludeRule, ExcludeRule:
+	default:
+		return errors.New("the rule action '" + string(o.Action) + "' is not supported")
+	}
+
+	switch o.Type {
+	case AnyFileType, RegularFileType, DirectoryFileType, SymlinkFileType:
+	default:
+		return errors.New("the rule file type '" + string(o.Type) + "' is not supported")
+	}
+
+	if o.MinSize < 0 || o.MaxSize < 0 {
+		return errors.New("rule sizes cannot be negative")
+	}
+
+	if o.MaxSize > 0 && o.MinSize > o.MaxSize {
+		return errors.New("a rule's minimum size cannot be greater than its maximum size")
+	}
+
+	for _, glob := range []string{o.Name, o.Path} {
+		if len(glob) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(glob, "!") {
+			return errors.New("the rule glob pattern '" + glob + "' cannot be negated")
+		}
+
+		err := validateGlob(glob)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// excludedByRules evaluates the Config's Rules for a file or directory.
+// The first Rule that matches determines whether it is excluded. It is
+// not excluded if no Rule matches.
+func (o Config) excludedByRules(info os.FileInfo, relPath string) bool {
+	for _, rule := range o.Rules {
+		if rule.matches(info, relPath) {
+			return rule.Action == ExcludeRule
+		}
+	}
+
+	return false
+}
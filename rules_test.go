@@ -0,0 +1,66 @@
+package watcher
+
+import (
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestScanFilesRecursively_Rules(t *testing.T) {
+	rootDirPath := tempDataDirPath(t)
+
+	writeTestFile(t, path.Join(rootDirPath, "big.txt"), strings.Repeat("x", 100))
+	writeTestFile(t, path.Join(rootDirPath, "important-big.txt"), strings.Repeat("x", 100))
+
+	config := Config{
+		RootDirPath:  rootDirPath,
+		ScanCriteria: []string{searchFileExt},
+		Rules: []Rule{
+			{Action: IncludeRule, Name: "important-*"},
+			{Action: ExcludeRule, MinSize: 50},
+			{Action: ExcludeRule, Path: "subdir/subsubdir", Type: DirectoryFileType},
+			{Action: ExcludeRule, Path: "**/*2.txt", Type: RegularFileType},
+		},
+	}
+
+	err := config.Rules[0].validate()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	result, err := ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"file1.txt",
+		"important-big.txt",
+		"subdirfile1.txt",
+	})
+}
+
+func TestRule_validate(t *testing.T) {
+	invalid := []Rule{
+		{},
+		{Action: "junk"},
+		{Action: IncludeRule, Type: "junk"},
+		{Action: IncludeRule, MinSize: -1},
+		{Action: IncludeRule, MinSize: 10, MaxSize: 5},
+		{Action: IncludeRule, Name: "{a"},
+		{Action: ExcludeRule, Path: "a/["},
+		{Action: ExcludeRule, Name: "!*.log"},
+		{Action: IncludeRule, Path: "!logs/**"},
+	}
+
+	for _, rule := range invalid {
+		if rule.validate() == nil {
+			t.Fatal("Invalid rule did not generate an error -", rule)
+		}
+	}
+
+	err := Rule{Action: ExcludeRule, Name: "*.{log,tmp}", MaxSize: 10}.validate()
+	if err != nil {
+		t.Fatal("Valid rule generated an error -", err.Error())
+	}
+}
@@ -1,8 +1,10 @@
 package watcher
 
 import (
-	"io/ioutil"
+	"io/fs"
+	"os"
 	"path"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -11,6 +13,12 @@ import (
 // modified files.
 type ScanResult struct {
 	FilePathsToInfo map[string]MatchInfo
+
+	// Failures maps the paths of the roots and archives that could not
+	// be read to the reason that they could not be read. A Watcher does
+	// not report the files that it previously found in them as deleted,
+	// and reports the failures in a separate Change (see Change.IsErr).
+	Failures map[string]error
 }
 
 // MatchInfo provides information about a single modified file that met the
@@ -19,6 +27,57 @@ type MatchInfo struct {
 	Path      string
 	ModTime   time.Time
 	MatchedOn string
+
+	// Size is the size of the file in bytes.
+	Size int64
+
+	// Device and Inode are the device and inode numbers of the file.
+	// They are zero if the platform or file system does not provide
+	// them.
+	Device uint64
+	Inode  uint64
+
+	// ChangeTime is the time that the file's metadata last changed
+	// (its ctime). It is zero if the platform or file system does not
+	// provide it.
+	ChangeTime time.Time
+
+	// Mode is the file's mode and permission bits.
+	Mode os.FileMode
+
+	// Uid and Gid are the user and group IDs of the file's owner.
+	// They are zero if the platform or file system does not provide
+	// them.
+	Uid uint32
+	Gid uint32
+
+	// Hash is the hex-encoded digest of the file's contents. It is
+	// empty unless the Config's HashContents is true, or the file was
+	// modified shortly before a scan (see Config.ModTimeGranularity).
+	Hash string
+
+	// Captures maps the names of capture groups in the regular
+	// expression that matched the file to their values. It is nil
+	// unless the Config's CriteriaType is RegexpCriteria or
+	// PathRegexpCriteria.
+	Captures map[string]string
+
+	// Root is the root directory that the file was found in.
+	// It is empty for files scanned by ScanFilePaths.
+	Root string
+
+	// Archive is the path of the archive that contains the file
+	// if the file was found by ScanArchives. It is empty otherwise.
+	Archive string
+
+	// Target is the resolved path of the file if it is a symlink,
+	// or if it was found in a directory that is a symlink (see
+	// Config.Symlinks). It is empty otherwise.
+	Target string
+
+	// checksum is the CRC-32 checksum of the file if it is inside of
+	// a zip file (see ScanArchives).
+	checksum uint32
 }
 
 // ScanFilesInDirectory scans a directory for files ending with a particular
@@ -39,38 +98,7 @@ type MatchInfo struct {
 // as '.cfg', the function will return a ScanResult containing
 // 'path/to/My Files/Awesome.cfg'.
 func ScanFilesInDirectory(config Config) (ScanResult, error) {
-	subInfos, err := ioutil.ReadDir(config.RootDirPath)
-	if err != nil {
-		return ScanResult{}, &ScanError{
-			reason:         err.Error(),
-			rootReadFailed: true,
-		}
-	}
-
-	result := ScanResult{
-		FilePathsToInfo: make(map[string]MatchInfo),
-	}
-
-	for _, sub := range subInfos {
-		if sub.IsDir() {
-			continue
-		}
-
-		suffix, matches := matchesSuffixes(sub.Name(), config.ScanCriteria)
-		if !matches {
-			continue
-		}
-
-		filePath := path.Join(config.RootDirPath, sub.Name())
-
-		result.FilePathsToInfo[filePath] = MatchInfo{
-			Path:      filePath,
-			MatchedOn: suffix,
-			ModTime:   sub.ModTime(),
-		}
-	}
-
-	return result, nil
+	return scanTree(config, 1, 1)
 }
 
 // ScanFilesInSubdirectories scans a directory's subdirectories for files
@@ -95,51 +123,314 @@ func ScanFilesInDirectory(config Config) (ScanResult, error) {
 // as '.cfg', the function will return a ScanResult containing
 // 'path/to/My Files/stuff/Awesome.cfg'.
 func ScanFilesInSubdirectories(config Config) (ScanResult, error) {
-	subInfos, err := ioutil.ReadDir(config.RootDirPath)
+	return scanTree(config, 2, 2)
+}
+
+// ScanFilesRecursively scans a directory and all of its subdirectories for
+// files with a particular suffix. The Config's MinDepth and MaxDepth limit
+// which files are matched according to their depth. Files in the root
+// directory have a depth of 1, files in its subdirectories have a depth
+// of 2, and so on.
+//
+// Consider the following file tree:
+//
+//	My Files/
+//	|
+//	|-- Awesome.cfg
+//	|
+//	|-- stuff/
+//	   |
+//	   |-- Neat.cfg
+//	   |
+//	   |-- more-stuff/
+//	      |
+//	      |-- Cool.cfg
+//
+// If you specify the root directory to scan as 'My Files', and the file suffix
+// as '.cfg', the function will return a ScanResult containing all three files.
+// If MinDepth is 2 and MaxDepth is 2, the function will only return
+// 'path/to/My Files/stuff/Neat.cfg'.
+func ScanFilesRecursively(config Config) (ScanResult, error) {
+	return scanTree(config, config.MinDepth, config.MaxDepth)
+}
+
+// ScanFS creates a ScanFunc that executes the provided ScanFunc against
+// a file system other than the operating system's, such as an os.DirFS,
+// an embed.FS, or a testing/fstest.MapFS. Paths in the Config are
+// interpreted as paths in the fs.FS (see fs.ValidPath). For example:
+//
+//	ScanFS(os.DirFS("/etc"), ScanFilesInDirectory)
+//
+// with a RootDirPath of "app" scans the same files as ScanFilesInDirectory
+// would scan in "/etc/app", but reports them as "app/<name>".
+//
+// Unlike the Config's FS, the fs.FS is not visible to NewWatcher, which
+// may therefore select NotifyBackend. The operating system cannot notify
+// a Watcher of changes to an fs.FS, so the ScanFunc fails with a ScanError
+// if it is used by such a Watcher. Set the Config's Backend to
+// PollingBackend when using ScanFS, or set the Config's FS instead.
+func ScanFS(fsys fs.FS, scanFunc ScanFunc) ScanFunc {
+	return func(config Config) (ScanResult, error) {
+		if config.notified {
+			return ScanResult{}, &ScanError{
+				reason: "ScanFS cannot be used with the notify backend - set the Config's FS instead",
+			}
+		}
+
+		config.FS = fsys
+		return scanFunc(config)
+	}
+}
+
+// ScanFilePaths scans the files specified in the Config's FilePaths. Unlike
+// the other scan functions, it does not use the Config's RootDirPath or
+// ScanCriteria. Each file's MatchInfo.MatchedOn is set to its path. A file
+// that does not exist is not an error. It is simply omitted from the
+// ScanResult until it is created. A file that cannot be read for another
+// reason (e.g., because of its permissions) is added to the ScanResult's
+// Failures.
+func ScanFilePaths(config Config) (ScanResult, error) {
+	fsys := config.fileSystem()
+	result := ScanResult{
+		FilePathsToInfo: make(map[string]MatchInfo),
+	}
+
+	for _, filePath := range config.FilePaths {
+		info, err := fsys.lstat(filePath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			if result.Failures == nil {
+				result.Failures = make(map[string]error)
+			}
+
+			result.Failures[filePath] = &ScanError{
+				reason: err.Error(),
+			}
+			continue
+		}
+
+		var target string
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			switch config.Symlinks {
+			case IgnoreSymlinks:
+				continue
+			case FollowSymlinks:
+				info, err = fsys.stat(filePath)
+				if err != nil {
+					continue
+				}
+			}
+
+			target, _ = fsys.evalSymlinks(filePath)
+		}
+
+		if info.IsDir() {
+			continue
+		}
+
+		stat := statInfo(info)
+
+		result.FilePathsToInfo[filePath] = MatchInfo{
+			Path:       filePath,
+			MatchedOn:  filePath,
+			ModTime:    info.ModTime(),
+			Size:       info.Size(),
+			Device:     stat.device,
+			Inode:      stat.inode,
+			ChangeTime: stat.changeTime,
+			Mode:       info.Mode(),
+			Uid:        stat.uid,
+			Gid:        stat.gid,
+			Target:     target,
+		}
+	}
+
+	return result, nil
+}
+
+// scanTree scans the Config's RootDirPath and Roots for files whose depth
+// is between minDepth and maxDepth (inclusive). The depth is not limited if
+// maxDepth is less than 1. A root that cannot be read is added to the
+// ScanResult's Failures. The scan fails if none of the roots can be read.
+func scanTree(config Config, minDepth int, maxDepth int) (ScanResult, error) {
+	result := ScanResult{
+		FilePathsToInfo: make(map[string]MatchInfo),
+	}
+
+	roots := config.roots()
+	failed := 0
+
+	for _, root := range roots {
+		rootConfig := config
+		rootConfig.RootDirPath = root.Path
+		rootConfig.ScanCriteria = root.ScanCriteria
+
+		err := scanRoot(rootConfig, minDepth, maxDepth, result)
+		if err != nil {
+			if result.Failures == nil {
+				result.Failures = make(map[string]error)
+			}
+
+			result.Failures[root.Path] = err
+			failed++
+		}
+	}
+
+	if len(roots) > 0 && failed == len(roots) {
+		return ScanResult{}, failuresError(result.Failures)
+	}
+
+	return result, nil
+}
+
+// scanRoot scans the Config's RootDirPath and adds the matching files
+// to the ScanResult.
+func scanRoot(config Config, minDepth int, maxDepth int, result ScanResult) error {
+	fsys := config.fileSystem()
+
+	subInfos, err := fsys.readDir(config.RootDirPath)
 	if err != nil {
-		return ScanResult{}, &ScanError{
+		return &ScanError{
 			reason:         err.Error(),
 			rootReadFailed: true,
 		}
 	}
 
-	result := ScanResult{
-		FilePathsToInfo: make(map[string]MatchInfo),
+	s := &scanner{
+		config:   config,
+		fsys:     fsys,
+		minDepth: minDepth,
+		maxDepth: maxDepth,
+		result:   result,
+		regexps:  config.criteriaRegexps(),
+	}
+
+	rootInfo, err := fsys.stat(config.RootDirPath)
+	if err == nil {
+		s.ancestors = append(s.ancestors, rootInfo)
+	}
+
+	s.scanDir(config.RootDirPath, "", "", subInfos, 1)
+
+	return nil
+}
+
+type scanner struct {
+	config    Config
+	fsys      fileSystem
+	minDepth  int
+	maxDepth  int
+	ignores   []ignoreFile
+	ancestors []os.FileInfo
+	result    ScanResult
+
+	// regexps are the compiled ScanCriteria, which are compiled once
+	// per scan rather than once per file.
+	regexps []*regexp.Regexp
+}
+
+// scanDir scans a directory's files and subdirectories. If the directory
+// was found by following a symlink, targetDirPath is the directory's
+// resolved path. Otherwise, it is empty.
+func (o *scanner) scanDir(dirPath string, relDirPath string, targetDirPath string, subInfos []os.FileInfo, depth int) {
+	if o.config.UseIgnoreFiles {
+		ignoreFileName := defaultIgnoreFileName
+		if len(o.config.IgnoreFileName) > 0 {
+			ignoreFileName = o.config.IgnoreFileName
+		}
+
+		contents, err := o.fsys.readFile(path.Join(dirPath, ignoreFileName))
+		if err == nil {
+			o.ignores = append(o.ignores, parseIgnoreFile(relDirPath, string(contents)))
+			defer func() {
+				o.ignores = o.ignores[:len(o.ignores)-1]
+			}()
+		}
 	}
 
 	for _, sub := range subInfos {
-		if !sub.IsDir() {
+		subPath := path.Join(dirPath, sub.Name())
+		subRelPath := path.Join(relDirPath, sub.Name())
+
+		if isIgnored(o.ignores, subRelPath, sub.IsDir()) {
 			continue
 		}
 
-		subDirPath := path.Join(config.RootDirPath, sub.Name())
-
-		children, childErr := ioutil.ReadDir(subDirPath)
-		if childErr != nil {
+		if o.config.excludedByRules(sub, subRelPath) {
 			continue
 		}
 
-		for _, c := range children {
-			if c.IsDir() {
+		info := sub
+		var target string
+
+		if sub.Mode()&os.ModeSymlink != 0 {
+			switch o.config.Symlinks {
+			case IgnoreSymlinks:
 				continue
+			case FollowSymlinks:
+				var statErr error
+				info, statErr = o.fsys.stat(subPath)
+				if statErr != nil {
+					continue
+				}
 			}
 
-			suffix, matches := matchesSuffixes(c.Name(), config.ScanCriteria)
-			if !matches {
+			target, _ = o.fsys.evalSymlinks(subPath)
+		} else if len(targetDirPath) > 0 {
+			target = path.Join(targetDirPath, sub.Name())
+		}
+
+		if info.IsDir() {
+			if o.maxDepth > 0 && depth >= o.maxDepth {
 				continue
 			}
 
-			cPath := path.Join(subDirPath, c.Name())
+			if containsSameFile(o.ancestors, info) {
+				continue
+			}
 
-			result.FilePathsToInfo[cPath] = MatchInfo{
-				Path:      cPath,
-				MatchedOn: suffix,
-				ModTime:   c.ModTime(),
+			children, childErr := o.fsys.readDir(subPath)
+			if childErr != nil {
+				continue
 			}
+
+			o.ancestors = append(o.ancestors, info)
+			o.scanDir(subPath, subRelPath, target, children, depth+1)
+			o.ancestors = o.ancestors[:len(o.ancestors)-1]
+			continue
 		}
-	}
 
-	return result, nil
+		if depth < o.minDepth {
+			continue
+		}
+
+		criterion, captures, matches := o.config.matchCriteria(sub.Name(), subRelPath, o.regexps)
+		if !matches {
+			continue
+		}
+
+		stat := statInfo(info)
+
+		o.result.FilePathsToInfo[subPath] = MatchInfo{
+			Path:       subPath,
+			MatchedOn:  criterion,
+			ModTime:    info.ModTime(),
+			Size:       info.Size(),
+			Device:     stat.device,
+			Inode:      stat.inode,
+			ChangeTime: stat.changeTime,
+			Mode:       info.Mode(),
+			Uid:        stat.uid,
+			Gid:        stat.gid,
+			Captures:   captures,
+			Target:     target,
+			Root:       o.config.RootDirPath,
+		}
+	}
 }
 
 func matchesSuffixes(s string, suffixes []string) (string, bool) {
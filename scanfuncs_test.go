@@ -0,0 +1,253 @@
+package watcher
+
+import (
+	"os"
+	"path"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestScanFilesRecursively(t *testing.T) {
+	config := Config{
+		RootDirPath:  testDataDirPath(),
+		ScanCriteria: []string{searchFileExt},
+	}
+
+	result, err := ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"file1.txt",
+		"file2.txt",
+		"subdirfile1.txt",
+		"subdirfile2.txt",
+		"subsubdirfile.txt",
+	})
+
+	config.MaxDepth = 1
+	result, err = ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"file1.txt",
+		"file2.txt",
+	})
+
+	config.MinDepth = 2
+	config.MaxDepth = 0
+	result, err = ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"subdirfile1.txt",
+		"subdirfile2.txt",
+		"subsubdirfile.txt",
+	})
+
+	config.MinDepth = 2
+	config.MaxDepth = 2
+	result, err = ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"subdirfile1.txt",
+		"subdirfile2.txt",
+	})
+}
+
+func TestScanFilesRecursively_RootReadErr(t *testing.T) {
+	_, err := ScanFilesRecursively(Config{
+		RootDirPath:  path.Join(testDataDirPath(), "missing"),
+		ScanCriteria: []string{searchFileExt},
+	})
+	if err == nil {
+		t.Fatal("Missing root directory did not generate an error")
+	}
+
+	if !err.(*ScanError).RootDirectoryReadFailed() {
+		t.Fatal("Error should indicate that the root directory could not be read")
+	}
+}
+
+func assertScanResultBaseNames(t *testing.T, result ScanResult, exp []string) {
+	var baseNames []string
+
+	for filePath, info := range result.FilePathsToInfo {
+		if filePath != info.Path {
+			t.Fatal("Path does not match MatchInfo -", filePath, info.Path)
+		}
+
+		baseNames = append(baseNames, path.Base(filePath))
+	}
+
+	sort.Strings(baseNames)
+	sort.Strings(exp)
+
+	if len(baseNames) != len(exp) {
+		t.Fatal("Got unexpected files -", baseNames)
+	}
+
+	for i := range exp {
+		if baseNames[i] != exp[i] {
+			t.Fatal("Got unexpected files -", baseNames)
+		}
+	}
+}
+
+func TestScanFilePaths(t *testing.T) {
+	rootDirPath := tempDataDirPath(t)
+	otherDirPath := t.TempDir()
+
+	existing := path.Join(rootDirPath, "subdir", "subdirfile1.txt")
+	missing := path.Join(otherDirPath, "missing.cfg")
+
+	config := Config{
+		FilePaths: []string{existing, missing, path.Join(rootDirPath, "subdir")},
+	}
+
+	result, err := ScanFilePaths(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"subdirfile1.txt",
+	})
+
+	if result.FilePathsToInfo[existing].MatchedOn != existing {
+		t.Fatal("Got unexpected MatchedOn -", result.FilePathsToInfo[existing].MatchedOn)
+	}
+
+	writeTestFile(t, missing, "hello")
+
+	result, err = ScanFilePaths(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"missing.cfg",
+		"subdirfile1.txt",
+	})
+}
+
+func TestScanFilePaths_Failure(t *testing.T) {
+	dirPath := t.TempDir()
+	parentPath := path.Join(dirPath, "parent")
+	unreadable := path.Join(parentPath, "unreadable.txt")
+	readable := path.Join(dirPath, "readable.txt")
+	writeTestFile(t, unreadable, "hello")
+	writeTestFile(t, readable, "hello")
+
+	w := newDefaultWatcher(Config{
+		FilePaths: []string{unreadable, readable},
+		ScanFunc:  ScanFilePaths,
+	})
+
+	w.scan(w.config)
+
+	// Replacing the parent directory with a file makes the path
+	// unreadable rather than missing.
+	err := os.RemoveAll(parentPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	writeTestFile(t, parentPath, "hello")
+
+	writeTestFile(t, readable, "goodbye")
+	future := time.Now().Add(time.Hour)
+	err = os.Chtimes(readable, future, future)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	result, err := ScanFilePaths(w.config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if result.Failures[unreadable] == nil || len(result.Failures) != 1 {
+		t.Fatal("Unreadable file was not reported as a failure -", result.Failures)
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"readable.txt",
+	})
+
+	change := w.scan(w.config)
+	if change.failures == nil {
+		t.Fatal("Unreadable file was not reported as a failure")
+	}
+
+	assertStrings(t, "updated", change.UpdatedFilePaths(), []string{readable})
+	assertStrings(t, "deleted", change.DeletedFilePaths(), nil)
+}
+
+func TestScanFilesRecursively_Roots(t *testing.T) {
+	firstDirPath := tempDataDirPath(t)
+	secondDirPath := t.TempDir()
+
+	writeTestFile(t, path.Join(secondDirPath, "app.cfg"), "hello")
+	writeTestFile(t, path.Join(secondDirPath, "plugins", "plugin.txt"), "hello")
+
+	config := Config{
+		RootDirPath:  path.Join(firstDirPath, "subdir"),
+		ScanCriteria: []string{searchFileExt},
+		Roots: []Root{
+			{Path: secondDirPath, ScanCriteria: []string{".cfg"}},
+			{Path: path.Join(secondDirPath, "plugins")},
+		},
+		MaxDepth: 1,
+	}
+
+	result, err := ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"app.cfg",
+		"plugin.txt",
+		"subdirfile1.txt",
+		"subdirfile2.txt",
+	})
+
+	for filePath, info := range result.FilePathsToInfo {
+		if path.Dir(filePath) != info.Root {
+			t.Fatal("Got unexpected root for", filePath, "-", info.Root)
+		}
+	}
+
+	missingDirPath := path.Join(secondDirPath, "missing")
+	config.Roots = append(config.Roots, Root{Path: missingDirPath})
+
+	result, err = ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if result.Failures[missingDirPath] == nil || len(result.Failures) != 1 {
+		t.Fatal("Missing root directory was not reported as a failure -", result.Failures)
+	}
+
+	if len(result.FilePathsToInfo) != 4 {
+		t.Fatal("Got unexpected files -", result.FilePathsToInfo)
+	}
+
+	config.RootDirPath = missingDirPath
+	config.Roots = []Root{{Path: missingDirPath}}
+
+	_, err = ScanFilesRecursively(config)
+	if err == nil {
+		t.Fatal("Missing root directories did not generate an error")
+	}
+}
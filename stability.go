@@ -0,0 +1,167 @@
+package watcher
+
+import (
+	"time"
+)
+
+// pendingFile is a created or updated file that is held back
+// because it may still be being written.
+type pendingFile struct {
+	size    int64
+	modTime time.Time
+
+	// state is the state that the file had when it became pending.
+	// For example, a file that exists when the Watcher first scans
+	// is reported as updated, even though it is not in the last
+	// ScanResult when it becomes stable.
+	state changeState
+
+	// since is the time that the file's current size and
+	// modification time were first seen.
+	since time.Time
+
+	// scans is the number of consecutive scans in which the file's
+	// size and modification time did not change.
+	scans int
+}
+
+// stable returns true if a pending file has been stable for the
+// Config's StableDelay and StableScans.
+func (o Config) stable(file pendingFile, now time.Time) bool {
+	if o.StableDelay > 0 && now.Sub(file.since) < o.StableDelay {
+		return false
+	}
+
+	if o.StableScans > 0 && file.scans < o.StableScans {
+		return false
+	}
+
+	return true
+}
+
+// holdUnstable removes the created, existing, and updated files that are
+// not yet stable from the Change, along with their attribute changes. It
+// returns the files that are pending, which replace the Watcher's pending
+// files.
+func (o *defaultWatcher) holdUnstable(config Config, change *defaultChange, now time.Time) map[string]pendingFile {
+	if config.StableDelay <= 0 && config.StableScans <= 0 {
+		o.pending = nil
+		return nil
+	}
+
+	pending := make(map[string]pendingFile)
+	ready := make(map[changeState][]MatchInfo)
+
+	for _, state := range []changeState{created, existing, updated} {
+		for _, info := range change.stateToInfo[state] {
+			file, exists := o.pending[info.Path]
+			switch {
+			case !exists:
+				file = pendingFile{
+					size:    info.Size,
+					modTime: info.ModTime,
+					state:   state,
+					since:   now,
+				}
+			case file.size == info.Size && file.modTime.Equal(info.ModTime):
+				file.scans++
+			default:
+				file.size = info.Size
+				file.modTime = info.ModTime
+				file.since = now
+				file.scans = 0
+			}
+
+			if config.stable(file, now) {
+				ready[file.state] = append(ready[file.state], info)
+				continue
+			}
+
+			pending[info.Path] = file
+		}
+	}
+
+	change.setState(created, ready[created])
+	change.setState(existing, ready[existing])
+	change.setState(updated, ready[updated])
+
+	var attributes []MatchInfo
+
+	for _, info := range change.stateToInfo[attributeChanged] {
+		_, isPending := pending[info.Path]
+		if !isPending {
+			attributes = append(attributes, info)
+		}
+	}
+
+	change.setState(attributeChanged, attributes)
+
+	o.pending = pending
+
+	return pending
+}
+
+// withLast returns a copy of the ScanResult in which held deletes and
+// pending files are replaced by their information from the last scan,
+// or removed if they were not in the last scan. This causes them to be
+// found again by the next scan.
+func (o *defaultWatcher) withLast(current ScanResult, held map[string]bool, pending map[string]pendingFile) ScanResult {
+	if len(held) == 0 && len(pending) == 0 {
+		return current
+	}
+
+	var filePaths []string
+
+	for filePath := range held {
+		filePaths = append(filePaths, filePath)
+	}
+
+	for filePath := range pending {
+		filePaths = append(filePaths, filePath)
+	}
+
+	result := ScanResult{
+		FilePathsToInfo: make(map[string]MatchInfo),
+	}
+
+	for filePath, info := range current.FilePathsToInfo {
+		result.FilePathsToInfo[filePath] = info
+	}
+
+	for _, filePath := range filePaths {
+		last, exists := o.last.FilePathsToInfo[filePath]
+		if exists {
+			result.FilePathsToInfo[filePath] = last
+		} else {
+			delete(result.FilePathsToInfo, filePath)
+		}
+	}
+
+	return result
+}
+
+// pendingDelay returns the time to wait before scanning again to find
+// out if pending files have become stable, and if held deletes are real.
+// The second return value is false if there are no pending files or
+// held deletes.
+func (o *defaultWatcher) pendingDelay(config Config) (time.Duration, bool) {
+	if len(o.pending) == 0 && len(o.held) == 0 {
+		return 0, false
+	}
+
+	var delay time.Duration
+	if config.StableScans > 0 || len(o.held) > 0 {
+		delay = config.refreshDelay()
+	}
+
+	if config.StableDelay > 0 {
+		for _, file := range o.pending {
+			remaining := time.Until(file.since.Add(config.StableDelay))
+			if remaining > delay {
+				delay = remaining
+			}
+		}
+	}
+
+	return delay, true
+}
@@ -0,0 +1,100 @@
+package watcher
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestDefaultWatcher_StableScans(t *testing.T) {
+	dirPath := t.TempDir()
+
+	w := newDefaultWatcher(Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{searchFileExt},
+		ScanFunc:     ScanFilesInDirectory,
+		StableScans:  1,
+	})
+
+	w.scan(w.config)
+
+	filePath := path.Join(dirPath, "upload.txt")
+	writeTestFile(t, filePath, "partial")
+
+	change := w.scan(w.config)
+	if change.hasChanges() {
+		t.Fatal("File that may still be written was reported -", change.CreatedFilePaths())
+	}
+
+	// Simulate the upload continuing.
+	writeTestFile(t, filePath, "partial contents")
+	modTime := time.Now().Add(time.Second)
+	err := os.Chtimes(filePath, modTime, modTime)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	change = w.scan(w.config)
+	if change.hasChanges() {
+		t.Fatal("File that may still be written was reported -", change.CreatedFilePaths())
+	}
+
+	change = w.scan(w.config)
+	if len(change.CreatedFilePaths()) != 1 || change.CreatedFilePaths()[0] != filePath {
+		t.Fatal("Did not get expected created file paths -", change.CreatedFilePaths())
+	}
+
+	change = w.scan(w.config)
+	if change.hasChanges() {
+		t.Fatal("Stable file was reported again")
+	}
+}
+
+func TestDefaultWatcher_StableDelay(t *testing.T) {
+	dirPath := t.TempDir()
+	filePath := path.Join(dirPath, "file.txt")
+	writeTestFile(t, filePath, "hello")
+
+	w := newDefaultWatcher(Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{searchFileExt},
+		ScanFunc:     ScanFilesInDirectory,
+		StableDelay:  100 * time.Millisecond,
+	})
+
+	change := w.scan(w.config)
+	if change.hasChanges() {
+		t.Fatal("File that may still be written was reported -", change.UpdatedFilePaths())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	change = w.scan(w.config)
+	if len(change.UpdatedFilePaths()) != 1 {
+		t.Fatal("Did not get expected updated file paths -", change.UpdatedFilePaths())
+	}
+
+	writeTestFile(t, filePath, "goodbye")
+
+	change = w.scan(w.config)
+	if change.hasChanges() {
+		t.Fatal("File that may still be written was reported -", change.UpdatedFilePaths())
+	}
+
+	err := os.Remove(filePath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	change = w.scan(w.config)
+	if len(change.UpdatedFilePaths()) != 0 || len(change.DeletedFilePaths()) != 1 {
+		t.Fatal("Did not get expected deleted file paths -", change.DeletedFilePaths())
+	}
+
+	if len(w.pending) != 0 {
+		t.Fatal("Deleted file is still pending -", w.pending)
+	}
+}
@@ -0,0 +1,14 @@
+package watcher
+
+import (
+	"time"
+)
+
+// fileStat contains platform-specific information about a file.
+type fileStat struct {
+	device     uint64
+	inode      uint64
+	changeTime time.Time
+	uid        uint32
+	gid        uint32
+}
@@ -0,0 +1,28 @@
+//go:build darwin || freebsd || netbsd
+// +build darwin freebsd netbsd
+
+package watcher
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// statInfo returns the information about a file that is not available
+// from os.FileInfo. Zero values are returned if it is not available
+// (e.g., for files in an fs.FS).
+func statInfo(info os.FileInfo) fileStat {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileStat{}
+	}
+
+	return fileStat{
+		device:     uint64(stat.Dev),
+		inode:      uint64(stat.Ino),
+		changeTime: time.Unix(stat.Ctimespec.Unix()),
+		uid:        stat.Uid,
+		gid:        stat.Gid,
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package watcher
+
+import (
+	"os"
+)
+
+// statInfo returns zero values on platforms whose os.FileInfo does not
+// provide the information (e.g., Windows).
+func statInfo(info os.FileInfo) fileStat {
+	return fileStat{}
+}
@@ -0,0 +1,40 @@
+package watcher
+
+import (
+	"os"
+)
+
+// SymlinkPolicy determines how symlinks are handled while scanning.
+type SymlinkPolicy string
+
+const (
+	// ReportSymlinks treats a symlink as a file, regardless of what it
+	// points to. The symlink's own modification time is reported, and
+	// the symlink is not followed.
+	ReportSymlinks SymlinkPolicy = ""
+
+	// IgnoreSymlinks skips symlinks.
+	IgnoreSymlinks SymlinkPolicy = "ignore"
+
+	// FollowSymlinks follows symlinks to their targets. A symlink to
+	// a file is reported with the target's modification time, and
+	// a symlink to a directory is scanned like a directory. Broken
+	// symlinks are skipped. A symlink that points to a directory that
+	// is already being scanned (i.e., that would cause a loop) is
+	// detected by comparing device and inode numbers, and is skipped.
+	FollowSymlinks SymlinkPolicy = "follow"
+)
+
+// containsSameFile returns true if the slice contains a file that is the
+// same file as info (i.e., it has the same device and inode numbers).
+// Symlinks are followed safely by checking that a directory is not one of
+// the directories that are currently being scanned.
+func containsSameFile(infos []os.FileInfo, info os.FileInfo) bool {
+	for i := range infos {
+		if os.SameFile(infos[i], info) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,87 @@
+package watcher
+
+import (
+	"os"
+	"path"
+	"testing"
+)
+
+func TestScanFilesRecursively_Symlinks(t *testing.T) {
+	rootDirPath := tempDataDirPath(t)
+
+	symlink(t, "file1.txt", path.Join(rootDirPath, "link.txt"))
+	symlink(t, "missing.txt", path.Join(rootDirPath, "broken.txt"))
+	symlink(t, "subdir/subsubdir", path.Join(rootDirPath, "linkdir"))
+	symlink(t, "..", path.Join(rootDirPath, "subdir", "loop"))
+
+	config := Config{
+		RootDirPath:  rootDirPath,
+		ScanCriteria: []string{searchFileExt},
+	}
+
+	result, err := ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"broken.txt",
+		"file1.txt",
+		"file2.txt",
+		"link.txt",
+		"subdirfile1.txt",
+		"subdirfile2.txt",
+		"subsubdirfile.txt",
+	})
+
+	linkInfo := result.FilePathsToInfo[path.Join(rootDirPath, "link.txt")]
+	if linkInfo.Target != path.Join(rootDirPath, "file1.txt") {
+		t.Fatal("Got unexpected target -", linkInfo.Target)
+	}
+
+	config.Symlinks = IgnoreSymlinks
+	result, err = ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"file1.txt",
+		"file2.txt",
+		"subdirfile1.txt",
+		"subdirfile2.txt",
+		"subsubdirfile.txt",
+	})
+
+	config.Symlinks = FollowSymlinks
+	result, err = ScanFilesRecursively(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assertScanResultBaseNames(t, result, []string{
+		"file1.txt",
+		"file2.txt",
+		"link.txt",
+		"subdirfile1.txt",
+		"subdirfile2.txt",
+		"subsubdirfile.txt",
+		"subsubdirfile.txt",
+	})
+
+	followedInfo, ok := result.FilePathsToInfo[path.Join(rootDirPath, "linkdir", "subsubdirfile.txt")]
+	if !ok {
+		t.Fatal("File in symlinked directory was not found")
+	}
+
+	if followedInfo.Target != path.Join(rootDirPath, "subdir", "subsubdir", "subsubdirfile.txt") {
+		t.Fatal("Got unexpected target -", followedInfo.Target)
+	}
+}
+
+func symlink(t *testing.T, target string, linkPath string) {
+	err := os.Symlink(target, linkPath)
+	if err != nil {
+		t.Skip("Failed to create symlink -", err.Error())
+	}
+}
@@ -2,18 +2,26 @@ package watcher
 
 import (
 	"errors"
+	"hash"
+	"io/fs"
+	"path"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
 )
 
 const (
-	defaultRefreshDelay = 10 * time.Second
+	defaultRefreshDelay       = 10 * time.Second
+	defaultModTimeGranularity = 2 * time.Second
 )
 
 const (
-	updated changeState = "updated"
-	deleted changeState = "deleted"
+	created          changeState = "created"
+	existing         changeState = "existing"
+	updated          changeState = "updated"
+	deleted          changeState = "deleted"
+	attributeChanged changeState = "attribute-changed"
 )
 
 type changeState string
@@ -26,23 +34,42 @@ type Watcher interface {
 	// Stop stops the Watcher.
 	Stop()
 
-	// Destroy stops the Watcher and closes the Config.Changes channel.
+	// Destroy stops the Watcher and closes the Config.Changes and
+	// Config.Events channels.
 	// This should only be called if you do not intend to use the Watcher.
 	Destroy()
 
 	// Config returns the Watcher's Config.
 	Config() *Config
+
+	// Backend returns the Backend that the Watcher uses to find changes.
+	Backend() Backend
 }
 
 type defaultWatcher struct {
-	mutex  *sync.Mutex
-	config Config
-	last   ScanResult
-	stop   chan struct{}
-	kill   chan struct{}
+	mutex   *sync.Mutex
+	running *sync.Mutex
+	config  Config
+	last    ScanResult
+	initial bool
+	seeded  bool
+	racy    map[string]bool
+	pending map[string]pendingFile
+	held    map[string]bool
+	batch   *debouncedChange
+	stop    chan struct{}
+	kill    chan struct{}
 }
 
 func (o *defaultWatcher) Start() {
+	o.start(o.loop)
+}
+
+// start starts the loop in a new goroutine once the previous loop has
+// exited. The loop is given the stop channel that belongs to this Start
+// call, so that a loop that was stopped cannot be revived by a later
+// Start.
+func (o *defaultWatcher) start(loop func(config Config, stop chan struct{})) {
 	o.mutex.Lock()
 	defer o.mutex.Unlock()
 
@@ -59,59 +86,206 @@ func (o *defaultWatcher) Start() {
 		return
 	}
 
-	go o.loop(o.config)
+	config := o.config
+	stop := o.stop
+
+	go func() {
+		// Wait for the previous loop to exit.
+		o.running.Lock()
+		defer o.running.Unlock()
+
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		// The first scan of a Watcher that was seeded with
+		// a Baseline is compared with the Baseline instead.
+		o.initial = !o.seeded
+		loop(config, stop)
+	}()
 }
 
-func (o *defaultWatcher) loop(config Config) {
-	delay := defaultRefreshDelay
-	if config.RefreshDelay > 0 {
-		delay = config.RefreshDelay
-	}
+func (o *defaultWatcher) loop(config Config, stop chan struct{}) {
+	delay := config.refreshDelay()
 
 	for {
-		time.Sleep(delay)
+		select {
+		case <-o.kill:
+			config.closeChannels()
+			return
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
 
-		current, err := config.ScanFunc(config)
-		change := &defaultChange{
-			scanResult:  current,
-			stateToInfo: make(map[changeState][]MatchInfo),
+		if !o.report(config, o.scan(config), stop) {
+			return
 		}
-		if err != nil {
-			change.err = err
-			config.Changes <- change
+	}
+}
+
+// scan executes the Config's ScanFunc and compares the result with
+// the result of the previous scan.
+func (o *defaultWatcher) scan(config Config) *defaultChange {
+	scanTime := time.Now()
+	current, err := config.ScanFunc(config)
+	change := &defaultChange{
+		time:        scanTime,
+		scanResult:  current,
+		stateToInfo: make(map[changeState][]MatchInfo),
+		previous:    make(map[string]MatchInfo),
+	}
+	if err != nil {
+		change.err = err
+		return change
+	}
+
+	if len(current.Failures) > 0 {
+		current = o.withFailedFiles(current)
+		change.scanResult = current
+		change.failures = failuresError(current.Failures)
+	}
+
+	if config.AtomicSaves {
+		current = config.withoutTempFiles(current)
+		change.scanResult = current
+	}
+
+	racy := config.racyFiles(current, scanTime)
+	config.hashContents(current, o.last, o.racy, racy)
+
+	for currentFilePath, current := range current.FilePathsToInfo {
+		last, exists := o.last.FilePathsToInfo[currentFilePath]
+		if !exists {
+			if !o.initial {
+				change.stateToInfo[created] = append(change.stateToInfo[created], current)
+				continue
+			}
+
+			switch config.InitialScan {
+			case InitialScanExisting:
+				change.stateToInfo[existing] = append(change.stateToInfo[existing], current)
+			case InitialScanBaseline:
+			default:
+				change.stateToInfo[updated] = append(change.stateToInfo[updated], current)
+			}
+
 			continue
 		}
 
-		for currentFilePath, current := range current.FilePathsToInfo {
-			last, exists := o.last.FilePathsToInfo[currentFilePath]
-			if exists && current.ModTime == last.ModTime {
-				continue
+		if o.seeded {
+			if config.baselineModified(last, current) {
+				change.stateToInfo[updated] = append(change.stateToInfo[updated], current)
+				change.previous[currentFilePath] = last
 			}
 
+			continue
+		}
+
+		if config.modified(last, current) {
 			change.stateToInfo[updated] = append(change.stateToInfo[updated], current)
+			change.previous[currentFilePath] = last
 		}
 
-		for lastFilePath, info := range o.last.FilePathsToInfo {
-			_, ok := current.FilePathsToInfo[lastFilePath]
-			if !ok {
-				change.stateToInfo[deleted] = append(change.stateToInfo[deleted], info)
-			}
+		if attributesChanged(last, current) {
+			change.stateToInfo[attributeChanged] = append(change.stateToInfo[attributeChanged], current)
+			change.previous[currentFilePath] = last
 		}
+	}
 
-		o.last = current
+	for lastFilePath, info := range o.last.FilePathsToInfo {
+		_, ok := current.FilePathsToInfo[lastFilePath]
+		if !ok {
+			change.stateToInfo[deleted] = append(change.stateToInfo[deleted], info)
+		}
+	}
 
-		select {
-		case <-o.kill:
-			close(config.Changes)
-			return
-		case <-o.stop:
-			return
-		default:
-			if len(change.stateToInfo) > 0 {
-				config.Changes <- change
-			}
+	change.pairRenames()
+
+	held := o.holdDeletes(config, change)
+	pending := o.holdUnstable(config, change, scanTime)
+
+	o.last = o.withLast(current, held, pending)
+	o.initial = false
+	o.seeded = false
+	o.racy = racy
+
+	return change
+}
+
+// report sends the Change to the Config's Changes channel if the scan
+// failed, and otherwise emits it (see emit). If some of the roots or
+// archives could not be read (see ScanResult.Failures), a Change that
+// describes the failures is sent first. It returns false if the Watcher
+// was stopped or destroyed.
+func (o *defaultWatcher) report(config Config, change *defaultChange, stop chan struct{}) bool {
+	if change.err != nil {
+		config.Changes <- change
+		return true
+	}
+
+	if change.failures != nil {
+		config.Changes <- &defaultChange{
+			err:        change.failures,
+			reconciled: change.reconciled,
+			time:       change.time,
+		}
+	}
+
+	return o.emit(config, o.debounce(config, change, time.Now()), stop)
+}
+
+// withFailedFiles returns a copy of the ScanResult that includes the files
+// that the previous scan found in the ScanResult's failed roots and
+// archives, so that they are not reported as deleted.
+func (o *defaultWatcher) withFailedFiles(current ScanResult) ScanResult {
+	result := ScanResult{
+		FilePathsToInfo: make(map[string]MatchInfo),
+		Failures:        current.Failures,
+	}
+
+	for filePath, info := range current.FilePathsToInfo {
+		result.FilePathsToInfo[filePath] = info
+	}
+
+	for filePath, info := range o.last.FilePathsToInfo {
+		_, found := result.FilePathsToInfo[filePath]
+		if found {
+			continue
+		}
+
+		_, pathFailed := current.Failures[info.Path]
+		_, rootFailed := current.Failures[info.Root]
+		_, archiveFailed := current.Failures[info.Archive]
+		if pathFailed || (len(info.Root) > 0 && rootFailed) || (len(info.Archive) > 0 && archiveFailed) {
+			result.FilePathsToInfo[filePath] = info
+		}
+	}
+
+	return result
+}
+
+// emit sends the Change to the Config's Changes channel, and its Events
+// to the Config's Events channel, if it contains any changes. It returns
+// false if the Watcher was stopped (i.e., the stop channel is closed)
+// or destroyed.
+func (o *defaultWatcher) emit(config Config, change *defaultChange, stop chan struct{}) bool {
+	select {
+	case <-o.kill:
+		config.closeChannels()
+		return false
+	case <-stop:
+		return false
+	default:
+		if change.hasChanges() {
+			config.Changes <- change
+			config.sendEvents(change)
 		}
 	}
+
+	return true
 }
 
 func (o *defaultWatcher) Destroy() {
@@ -148,33 +322,317 @@ func (o *defaultWatcher) Config() *Config {
 	return &o.config
 }
 
+func (o *defaultWatcher) Backend() Backend {
+	return PollingBackend
+}
+
+// InitialScanMode determines how the files found by a Watcher's first
+// scan are reported.
+type InitialScanMode string
+
+const (
+	// InitialScanUpdates reports the files found by the first scan
+	// as updated. This is the default.
+	InitialScanUpdates InitialScanMode = ""
+
+	// InitialScanExisting reports the files found by the first scan
+	// as existing (see Change.ExistingFilePaths).
+	InitialScanExisting InitialScanMode = "existing"
+
+	// InitialScanBaseline does not report the files found by the first
+	// scan. They are only used as the baseline for the next scan.
+	InitialScanBaseline InitialScanMode = "baseline"
+)
+
+// Root is a root directory to scan.
+type Root struct {
+	// Path is the path to the directory.
+	Path string
+
+	// ScanCriteria is a slice of strings that ScanFunc uses to match
+	// files in the directory. The Config's ScanCriteria are used if
+	// not specified.
+	ScanCriteria []string
+}
+
 // Config configures a Watcher.
 type Config struct {
 	// ScanFunc is the function to execute when it is time to
 	// scan for a change.
-	ScanFunc func(config Config) (ScanResult, error)
+	ScanFunc ScanFunc
 
 	// RefreshDelay is the time to wait between scans.
 	RefreshDelay time.Duration
 
+	// ReconcileDelay is the time to wait between reconciliation scans
+	// when using a Watcher that is notified of changes by the operating
+	// system, such as the Watcher created by NewInotifyWatcher.
+	// Reconciliation scans find changes that the operating system
+	// failed to report. Such changes are reported in a Change whose
+	// FromReconciliation method returns true. Reconciliation scans are
+	// disabled if the value is not greater than zero, unless NewWatcher
+	// selects NotifyBackend for AutoBackend (see NewWatcher).
+	ReconcileDelay time.Duration
+
 	// RootDirPath is the root directory to scan.
 	RootDirPath string
 
+	// FS is the file system that the Config's paths refer to. The
+	// operating system's file system is used if not specified.
+	// If specified, the paths must be valid fs.FS paths (see
+	// fs.ValidPath). A Watcher for an fs.FS always uses
+	// PollingBackend.
+	FS fs.FS
+
+	// Roots are additional root directories to scan. Each Root may
+	// specify its own ScanCriteria. The scan functions that scan
+	// RootDirPath scan each of the Roots as well, and report all of
+	// their files in a single ScanResult. The root directory that
+	// a file was found in is stored in its MatchInfo.Root.
+	Roots []Root
+
+	// FilePaths is a slice of paths to individual files that are scanned
+	// by ScanFilePaths. The files do not need to share a directory, or
+	// to exist. RootDirPath, Roots, and ScanCriteria are optional if
+	// FilePaths is specified.
+	FilePaths []string
+
 	// ScanCriteria is a slice of strings that ScanFunc uses
 	// to match files.
 	ScanCriteria []string
 
+	// CriteriaType determines how ScanCriteria are matched. The
+	// ScanCriteria are treated as file suffixes if not specified.
+	CriteriaType CriteriaType
+
+	// Rules is an ordered chain of Rule that include or exclude files
+	// and directories while scanning. The first Rule that matches a file
+	// or directory determines whether it is included in the scan. It is
+	// included if no Rule matches. Excluded files are never added to
+	// a ScanResult, and excluded directories are not scanned. Included
+	// files must still match the ScanCriteria.
+	//
+	// For example, the following Rules exclude log files, except for
+	// "important.log", and all files larger than 1 MB:
+	//
+	//	[]Rule{
+	//		{Action: IncludeRule, Name: "important.log"},
+	//		{Action: ExcludeRule, Name: "*.log"},
+	//		{Action: ExcludeRule, MinSize: 1000000},
+	//	}
+	Rules []Rule
+
+	// UseIgnoreFiles enables reading ignore files while scanning.
+	// An ignore file excludes files and directories from a scan using
+	// the same rules as a .gitignore file (see gitignore(5)), including
+	// negation, anchoring, directory-only patterns, and precedence of
+	// ignore files in subdirectories. Ignore files are read from
+	// RootDirPath and the directories beneath it on every scan, so
+	// changes to an ignore file apply to the next scan.
+	UseIgnoreFiles bool
+
+	// IgnoreFileName is the name of ignore files. It defaults to
+	// ".gitignore" if not specified.
+	IgnoreFileName string
+
+	// Symlinks determines how symlinks are handled while scanning.
+	// ReportSymlinks is used if not specified.
+	Symlinks SymlinkPolicy
+
+	// MinDepth is the minimum depth of files matched by
+	// ScanFilesRecursively. Files in RootDirPath have a depth of 1.
+	MinDepth int
+
+	// MaxDepth is the maximum depth of files matched by
+	// ScanFilesRecursively. The depth is not limited if the value
+	// is less than 1.
+	MaxDepth int
+
+	// HashContents enables content-based change detection. The contents
+	// of each matched file are hashed, and a file is only considered
+	// updated if its hash changes. A file is not hashed again if its
+	// size and modification time have not changed since the previous
+	// scan. The hash is stored in the file's MatchInfo.Hash.
+	HashContents bool
+
+	// NewHash creates the hash.Hash used when HashContents is true.
+	// It defaults to sha256.New if not specified.
+	NewHash func() hash.Hash
+
+	// ModTimeGranularity is the resolution of modification times on
+	// the file system. Two writes to a file within this duration may
+	// leave it with the same modification time. A file whose modification
+	// time is within this duration of the start of a scan is considered
+	// "racy". Its contents are hashed, and they are compared with its
+	// contents in the next scan so that such writes are not missed.
+	// It defaults to 2 seconds if not specified. Racy files are not
+	// detected if the value is less than zero.
+	ModTimeGranularity time.Duration
+
+	// StableDelay holds back created and updated files until their size
+	// and modification time have not changed for the specified duration.
+	// Such files are pending until then. They are reported once they
+	// are stable, and are not reported at all if they are deleted while
+	// pending. Files are not held back if the value is not greater
+	// than zero.
+	StableDelay time.Duration
+
+	// StableScans holds back created and updated files until their size
+	// and modification time have not changed for the specified number of
+	// consecutive scans (see StableDelay). If both StableDelay and
+	// StableScans are specified, a file must satisfy both. Files are not
+	// held back if the value is not greater than zero.
+	StableScans int
+
+	// AtomicSaves enables awareness of the way that editors and deploy
+	// tools save files atomically, such as by writing a temporary file
+	// and renaming it over the original file, or by moving the original
+	// file to a backup file before writing a new one. Files matching the
+	// TempFilePatterns are not reported, and a deleted file is held back
+	// for one scan. A file that is deleted and then created again by
+	// the next scan is reported as a single update.
+	AtomicSaves bool
+
+	// TempFilePatterns are glob patterns (see path.Match) that match the
+	// names of temporary files when AtomicSaves is true. Patterns may
+	// contain brace alternations such as "*.{swp,swo}". The
+	// DefaultTempFilePatterns are used if not specified.
+	TempFilePatterns []string
+
+	// DebounceDelay accumulates changes until no changes have been found
+	// for the specified duration, and then reports them in a single
+	// Change. The final state of each file is reported. For example,
+	// a file that is updated and then deleted is reported as deleted,
+	// and a file that is created and then deleted is not reported.
+	// Errors are reported immediately. Changes are not accumulated
+	// if the value is not greater than zero.
+	DebounceDelay time.Duration
+
+	// DebounceMaxWait is the maximum time to accumulate changes when
+	// DebounceDelay is specified. Accumulated changes are reported
+	// after this duration even if changes are still being found.
+	// The time is not limited if the value is not greater than zero.
+	DebounceMaxWait time.Duration
+
+	// InitialScan determines how the files that the Watcher finds in its
+	// first scan after being started are reported. This applies to the
+	// files that were not found by a previous scan. Changes to files
+	// that were found by a previous scan (i.e., before the Watcher was
+	// stopped) are reported as usual. InitialScanUpdates is used if not
+	// specified.
+	InitialScan InitialScanMode
+
+	// Baseline is the known state of the files at the time the Watcher
+	// is created. If specified, the Watcher's first scan is compared with
+	// the Baseline, and only the differences are reported. InitialScan
+	// does not apply to the first scan in that case. The MatchInfo of
+	// each file must specify its ModTime and Size. Its Path is set to
+	// the file's path in the Baseline if it is empty, and must otherwise
+	// match it. Files are compared by their ModTime and Size, or by their
+	// Hash if HashContents is true and the Hash is specified.
+	Baseline ScanResult
+
 	// Changes is the channel to receive a Change when a change occurs.
 	Changes chan Change
+
+	// Events is the channel to receive an Event for each file in
+	// a Change when a change occurs (see Change.Events). It is sent
+	// the Events after the Change is sent to the Changes channel.
+	// Errors are only reported to the Changes channel.
+	Events chan Event
+
+	// Backend is the Backend that the Watcher uses to find changes.
+	// AutoBackend is used if not specified.
+	Backend Backend
+
+	regexps map[string]*regexp.Regexp
+
+	// notified is true if the Config belongs to a Watcher that is
+	// notified of changes by the operating system (see NotifyBackend).
+	notified bool
 }
 
 func (o Config) IsValid() error {
-	if len(strings.TrimSpace(o.RootDirPath)) == 0 {
-		return errors.New("the directory path to watch cannot not be empty")
+	if len(o.FilePaths) == 0 {
+		roots := o.roots()
+		if len(roots) == 0 {
+			return errors.New("the directory path to watch cannot not be empty")
+		}
+
+		for _, root := range roots {
+			if len(strings.TrimSpace(root.Path)) == 0 {
+				return errors.New("the directory path to watch cannot not be empty")
+			}
+
+			if len(root.ScanCriteria) == 0 {
+				return errors.New("the file suffixes to match cannot not be empty")
+			}
+		}
+	}
+
+	for _, filePath := range o.FilePaths {
+		if len(strings.TrimSpace(filePath)) == 0 {
+			return errors.New("the file paths to watch cannot be empty")
+		}
 	}
 
-	if len(o.ScanCriteria) == 0 {
-		return errors.New("the file suffixes to match cannot not be empty")
+	if o.FS != nil {
+		for _, root := range o.roots() {
+			if !fs.ValidPath(root.Path) {
+				return errors.New("the directory path '" + root.Path + "' is not a valid fs.FS path")
+			}
+		}
+
+		for _, filePath := range o.FilePaths {
+			if !fs.ValidPath(filePath) {
+				return errors.New("the file path '" + filePath + "' is not a valid fs.FS path")
+			}
+		}
+
+		if o.Backend == NotifyBackend {
+			return errors.New("the notify backend does not support an fs.FS")
+		}
+	}
+
+	err := o.validateCriteria()
+	if err != nil {
+		return err
+	}
+
+	switch o.InitialScan {
+	case InitialScanUpdates, InitialScanExisting, InitialScanBaseline:
+	default:
+		return errors.New("the initial scan mode '" + string(o.InitialScan) + "' is not supported")
+	}
+
+	for filePath, info := range o.Baseline.FilePathsToInfo {
+		if len(filePath) == 0 {
+			return errors.New("baseline file paths cannot be empty")
+		}
+
+		if len(info.Path) > 0 && info.Path != filePath {
+			return errors.New("the baseline file path '" + filePath + "' does not match its MatchInfo path '" + info.Path + "'")
+		}
+	}
+
+	switch o.Symlinks {
+	case ReportSymlinks, IgnoreSymlinks, FollowSymlinks:
+	default:
+		return errors.New("the symlink policy '" + string(o.Symlinks) + "' is not supported")
+	}
+
+	for _, pattern := range o.TempFilePatterns {
+		err := validateGlob(pattern)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, rule := range o.Rules {
+		err := rule.validate()
+		if err != nil {
+			return err
+		}
 	}
 
 	if o.Changes == nil {
@@ -185,27 +643,70 @@ func (o Config) IsValid() error {
 		return errors.New("the scan function cannot be nil")
 	}
 
+	switch o.Backend {
+	case AutoBackend, PollingBackend, NotifyBackend:
+	default:
+		return errors.New("the backend '" + string(o.Backend) + "' is not supported")
+	}
+
 	return nil
 }
 
 // Change provides an interface for retrieving information about
 // changes that occurred.
+//
+// Files that did not exist in the previous scan are reported by the
+// CreatedFilePaths methods. Files that the Watcher finds in its first
+// scan after being started are reported according to the Config's
+// InitialScan, because the Watcher cannot know whether they are new.
+//
+// Files whose mode, owner, or group changed are reported by the
+// AttributeChangedFilePaths methods. A file is only reported by the
+// UpdatedFilePaths methods as well if its contents changed too.
 type Change interface {
 	IsErr() bool
 	RootReadErr() bool
 	ErrDetails() string
+	FromReconciliation() bool
+	CreatedFilePaths() []string
+	ExistingFilePaths() []string
 	UpdatedFilePaths() []string
 	DeletedFilePaths() []string
+	CreatedFilePathsWithSuffixes(suffixes []string) []string
+	ExistingFilePathsWithSuffixes(suffixes []string) []string
 	UpdatedFilePathsWithSuffixes(suffixes []string) []string
 	DeletedFilePathsWithSuffixes(suffixes []string) []string
+	CreatedFilePathsWithoutSuffixes(suffixes []string) []string
+	ExistingFilePathsWithoutSuffixes(suffixes []string) []string
 	UpdatedFilePathsWithoutSuffixes(suffixes []string) []string
 	DeletedFilePathsWithoutSuffixes(suffixes []string) []string
+	AttributeChangedFilePaths() []string
+	AttributeChangedFilePathsWithSuffixes(suffixes []string) []string
+	AttributeChangedFilePathsWithoutSuffixes(suffixes []string) []string
+
+	// RenamedFilePaths returns the files that were renamed or moved
+	// (see Rename).
+	RenamedFilePaths() []Rename
+	RenamedFilePathsWithSuffixes(suffixes []string) []Rename
+	RenamedFilePathsWithoutSuffixes(suffixes []string) []Rename
+
+	// Events returns an Event for each file that changed, sorted
+	// by path.
+	Events() []Event
 }
 
 type defaultChange struct {
 	err         error
+	failures    error
+	reconciled  bool
+	time        time.Time
 	scanResult  ScanResult
 	stateToInfo map[changeState][]MatchInfo
+	renames     []rename
+
+	// previous maps the paths of updated and attribute changed
+	// files to their information from the previous scan.
+	previous map[string]MatchInfo
 }
 
 func (o *defaultChange) IsErr() bool {
@@ -233,45 +734,89 @@ func (o *defaultChange) ErrDetails() string {
 	return ""
 }
 
-func (o *defaultChange) UpdatedFilePaths() []string {
-	var r []string
+func (o *defaultChange) FromReconciliation() bool {
+	return o.reconciled
+}
 
-	for _, c := range o.stateToInfo[updated] {
-		r = append(r, c.Path)
-	}
+func (o *defaultChange) CreatedFilePaths() []string {
+	return o.filePaths(created)
+}
 
-	return r
+func (o *defaultChange) ExistingFilePaths() []string {
+	return o.filePaths(existing)
+}
+
+func (o *defaultChange) UpdatedFilePaths() []string {
+	return o.filePaths(updated)
 }
 
 func (o *defaultChange) DeletedFilePaths() []string {
-	var r []string
+	return o.filePaths(deleted)
+}
 
-	for _, c := range o.stateToInfo[deleted] {
-		r = append(r, c.Path)
-	}
+func (o *defaultChange) AttributeChangedFilePaths() []string {
+	return o.filePaths(attributeChanged)
+}
 
-	return r
+func (o *defaultChange) CreatedFilePathsWithSuffixes(suffixes []string) []string {
+	return o.filePathsWithSuffixes(created, suffixes)
+}
+
+func (o *defaultChange) ExistingFilePathsWithSuffixes(suffixes []string) []string {
+	return o.filePathsWithSuffixes(existing, suffixes)
 }
 
 func (o *defaultChange) UpdatedFilePathsWithSuffixes(suffixes []string) []string {
+	return o.filePathsWithSuffixes(updated, suffixes)
+}
+
+func (o *defaultChange) DeletedFilePathsWithSuffixes(suffixes []string) []string {
+	return o.filePathsWithSuffixes(deleted, suffixes)
+}
+
+func (o *defaultChange) AttributeChangedFilePathsWithSuffixes(suffixes []string) []string {
+	return o.filePathsWithSuffixes(attributeChanged, suffixes)
+}
+
+func (o *defaultChange) CreatedFilePathsWithoutSuffixes(suffixes []string) []string {
+	return o.filePathsWithoutSuffixes(created, suffixes)
+}
+
+func (o *defaultChange) ExistingFilePathsWithoutSuffixes(suffixes []string) []string {
+	return o.filePathsWithoutSuffixes(existing, suffixes)
+}
+
+func (o *defaultChange) UpdatedFilePathsWithoutSuffixes(suffixes []string) []string {
+	return o.filePathsWithoutSuffixes(updated, suffixes)
+}
+
+func (o *defaultChange) DeletedFilePathsWithoutSuffixes(suffixes []string) []string {
+	return o.filePathsWithoutSuffixes(deleted, suffixes)
+}
+
+func (o *defaultChange) AttributeChangedFilePathsWithoutSuffixes(suffixes []string) []string {
+	return o.filePathsWithoutSuffixes(attributeChanged, suffixes)
+}
+
+// hasChanges returns true if the Change contains any changes.
+func (o *defaultChange) hasChanges() bool {
+	return len(o.stateToInfo) > 0 || len(o.renames) > 0
+}
+
+func (o *defaultChange) filePaths(state changeState) []string {
 	var r []string
 
-	for _, c := range o.stateToInfo[updated] {
-		for i := range suffixes {
-			if c.MatchedOn == suffixes[i] {
-				r = append(r, c.Path)
-				break
-			}
-		}
+	for _, c := range o.stateToInfo[state] {
+		r = append(r, c.Path)
 	}
 
 	return r
 }
 
-func (o *defaultChange) DeletedFilePathsWithSuffixes(suffixes []string) []string {
+func (o *defaultChange) filePathsWithSuffixes(state changeState, suffixes []string) []string {
 	var r []string
 
-	for _, c := range o.stateToInfo[deleted] {
+	for _, c := range o.stateToInfo[state] {
 		for i := range suffixes {
 			if c.MatchedOn == suffixes[i] {
 				r = append(r, c.Path)
@@ -283,11 +828,11 @@ func (o *defaultChange) DeletedFilePathsWithSuffixes(suffixes []string) []string
 	return r
 }
 
-func (o *defaultChange) UpdatedFilePathsWithoutSuffixes(suffixes []string) []string {
+func (o *defaultChange) filePathsWithoutSuffixes(state changeState, suffixes []string) []string {
 	var r []string
 
 OUTER:
-	for _, c := range o.stateToInfo[updated] {
+	for _, c := range o.stateToInfo[state] {
 		for i := range suffixes {
 			if c.MatchedOn == suffixes[i] {
 				continue OUTER
@@ -299,37 +844,158 @@ OUTER:
 	return r
 }
 
-func (o *defaultChange) DeletedFilePathsWithoutSuffixes(suffixes []string) []string {
-	var r []string
+// NewWatcher creates a new Watcher for the provided Config. The Watcher
+// uses the Backend specified in the Config. If the Config's Backend is
+// AutoBackend, the Watcher uses NotifyBackend if the operating system
+// can reliably report changes to the Config's RootDirPath, Roots, and
+// the directories containing its FilePaths. Otherwise, the Watcher uses
+// PollingBackend.
+//
+// A ScanFunc may find changes that the operating system does not report
+// (e.g., changes outside of the watched directories). Therefore, if
+// AutoBackend selects NotifyBackend and the Config's ReconcileDelay is
+// not specified, the ReconcileDelay defaults to the RefreshDelay. The
+// ScanFunc is then executed at least as often as it would be when
+// polling.
+func NewWatcher(config Config) (Watcher, error) {
+	err := config.IsValid()
+	if err != nil {
+		return &defaultWatcher{}, err
+	}
 
-OUTER:
-	for _, c := range o.stateToInfo[deleted] {
-		for i := range suffixes {
-			if c.MatchedOn == suffixes[i] {
-				continue OUTER
+	err = config.compileCriteria()
+	if err != nil {
+		return &defaultWatcher{}, err
+	}
+
+	switch config.Backend {
+	case NotifyBackend:
+		return newNotifyWatcher(config)
+	case AutoBackend:
+		if config.canNotify() {
+			notifyConfig := config
+			if notifyConfig.ReconcileDelay <= 0 {
+				notifyConfig.ReconcileDelay = notifyConfig.refreshDelay()
+			}
+
+			w, err := newNotifyWatcher(notifyConfig)
+			if err == nil {
+				return w, nil
 			}
 		}
-		r = append(r, c.Path)
 	}
 
-	return r
+	return newDefaultWatcher(config), nil
 }
 
-// NewWatcher creates a new Watcher for the provided Config.
-func NewWatcher(config Config) (Watcher, error) {
-	err := config.IsValid()
-	if err != nil {
-		return &defaultWatcher{}, err
+// canNotify returns true if the operating system can reliably report
+// changes to the directories that the Config refers to.
+func (o Config) canNotify() bool {
+	if o.FS != nil {
+		return false
 	}
+	for _, root := range o.roots() {
+		if !canNotify(root.Path) {
+			return false
+		}
+	}
+
+	for _, dirPath := range o.fileDirPaths() {
+		if !canNotify(dirPath) {
+			return false
+		}
+	}
+
+	return true
+}
 
+// closeChannels closes the Config's Changes and Events channels.
+func (o Config) closeChannels() {
+	close(o.Changes)
+
+	if o.Events != nil {
+		close(o.Events)
+	}
+}
+
+// refreshDelay returns the Config's RefreshDelay, or the default
+// RefreshDelay if it is not specified.
+func (o Config) refreshDelay() time.Duration {
+	if o.RefreshDelay > 0 {
+		return o.RefreshDelay
+	}
+
+	return defaultRefreshDelay
+}
+
+// roots returns the Config's RootDirPath (if it is specified) and Roots.
+// A Root that does not specify ScanCriteria is given the Config's
+// ScanCriteria.
+func (o Config) roots() []Root {
+	var roots []Root
+
+	if len(o.RootDirPath) > 0 {
+		roots = append(roots, Root{
+			Path:         o.RootDirPath,
+			ScanCriteria: o.ScanCriteria,
+		})
+	}
+
+	for _, root := range o.Roots {
+		if len(root.ScanCriteria) == 0 {
+			root.ScanCriteria = o.ScanCriteria
+		}
+
+		roots = append(roots, root)
+	}
+
+	return roots
+}
+
+// fileDirPaths returns the unique paths of the directories containing
+// the Config's FilePaths.
+func (o Config) fileDirPaths() []string {
+	var dirPaths []string
+	found := make(map[string]bool)
+
+	for _, filePath := range o.FilePaths {
+		dirPath := path.Dir(filePath)
+		if found[dirPath] {
+			continue
+		}
+
+		found[dirPath] = true
+		dirPaths = append(dirPaths, dirPath)
+	}
+
+	return dirPaths
+}
+
+func newDefaultWatcher(config Config) *defaultWatcher {
 	w := &defaultWatcher{
-		mutex:  &sync.Mutex{},
-		config: config,
-		kill:   make(chan struct{}),
-		stop:   make(chan struct{}),
+		mutex:   &sync.Mutex{},
+		running: &sync.Mutex{},
+		config:  config,
+		initial: true,
+		kill:    make(chan struct{}),
+		stop:    make(chan struct{}),
 	}
 
 	close(w.stop)
 
-	return w, nil
+	if config.Baseline.FilePathsToInfo != nil {
+		w.last = ScanResult{
+			FilePathsToInfo: make(map[string]MatchInfo),
+		}
+
+		for filePath, info := range config.Baseline.FilePathsToInfo {
+			info.Path = filePath
+			w.last.FilePathsToInfo[filePath] = info
+		}
+
+		w.initial = false
+		w.seeded = true
+	}
+
+	return w
 }
@@ -1,6 +1,7 @@
 package watcher
 
 import (
+	"io/ioutil"
 	"os"
 	"path"
 	"testing"
@@ -53,7 +54,77 @@ func TestConfig_IsValid(t *testing.T) {
 		t.Fatal("Empty scan func did not generate an error")
 	}
 
+	badGlobErr := Config{
+		RootDirPath:  "fdf",
+		ScanCriteria: []string{"*.{bla"},
+		CriteriaType: GlobCriteria,
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesInDirectory,
+	}.IsValid()
+	if badGlobErr == nil {
+		t.Fatal("Malformed glob pattern did not generate an error")
+	}
+
+	emptyRootErr := Config{
+		RootDirPath:  "fdf",
+		ScanCriteria: []string{".bla"},
+		Roots:        []Root{{Path: "fdf2", ScanCriteria: []string{".bla"}}, {Path: ""}},
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesInDirectory,
+	}.IsValid()
+	if emptyRootErr == nil {
+		t.Fatal("Empty root path did not generate an error")
+	}
+
+	rootWithoutCriteriaErr := Config{
+		Roots:    []Root{{Path: "fdf", ScanCriteria: []string{".bla"}}, {Path: "fdf2"}},
+		Changes:  make(chan Change),
+		ScanFunc: ScanFilesInDirectory,
+	}.IsValid()
+	if rootWithoutCriteriaErr == nil {
+		t.Fatal("Root without criteria did not generate an error")
+	}
+
+	emptyFilePathErr := Config{
+		FilePaths: []string{"/etc/app.cfg", " "},
+		Changes:   make(chan Change),
+		ScanFunc:  ScanFilePaths,
+	}.IsValid()
+	if emptyFilePathErr == nil {
+		t.Fatal("Empty file path did not generate an error")
+	}
+
+	badTempFilePatternErr := Config{
+		RootDirPath:      "fdf",
+		ScanCriteria:     []string{".bla"},
+		AtomicSaves:      true,
+		TempFilePatterns: []string{"*.{swp"},
+		Changes:          make(chan Change),
+		ScanFunc:         ScanFilesInDirectory,
+	}.IsValid()
+	if badTempFilePatternErr == nil {
+		t.Fatal("Malformed temporary file pattern did not generate an error")
+	}
+
 	err := Config{
+		FilePaths: []string{"/etc/app.cfg"},
+		Changes:   make(chan Change),
+		ScanFunc:  ScanFilePaths,
+	}.IsValid()
+	if err != nil {
+		t.Fatal("Valid config generated an error -", err.Error())
+	}
+
+	err = Config{
+		Roots:    []Root{{Path: "fdf", ScanCriteria: []string{".bla"}}},
+		Changes:  make(chan Change),
+		ScanFunc: ScanFilesInDirectory,
+	}.IsValid()
+	if err != nil {
+		t.Fatal("Valid config generated an error -", err.Error())
+	}
+
+	err = Config{
 		RootDirPath:  "fdf",
 		ScanCriteria: []string{".bla"},
 		Changes:      make(chan Change),
@@ -123,6 +194,46 @@ func TestNewWatcher(t *testing.T) {
 	}
 }
 
+func TestNewWatcher_Backend(t *testing.T) {
+	config := Config{
+		RootDirPath:  testDataDirPath(),
+		ScanCriteria: []string{searchFileExt},
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesInDirectory,
+		Backend:      PollingBackend,
+	}
+	w, err := NewWatcher(config)
+	if err != nil {
+		t.Fatal("Valid config generated an error -", err.Error())
+	}
+
+	if w.Backend() != PollingBackend {
+		t.Fatal("Got unexpected backend -", w.Backend())
+	}
+
+	config.Backend = AutoBackend
+	w, err = NewWatcher(config)
+	if err != nil {
+		t.Fatal("Valid config generated an error -", err.Error())
+	}
+	defer w.Destroy()
+
+	exp := PollingBackend
+	if canNotify(config.RootDirPath) {
+		exp = NotifyBackend
+	}
+
+	if w.Backend() != exp {
+		t.Fatal("Got unexpected backend -", w.Backend())
+	}
+
+	config.Backend = "junk"
+	_, err = NewWatcher(config)
+	if err == nil {
+		t.Fatal("Unknown backend did not generate an error")
+	}
+}
+
 func TestDefaultWatcherScanFilesInDirectory_Start(t *testing.T) {
 	config := Config{
 		RefreshDelay: 1 * time.Second,
@@ -130,6 +241,7 @@ func TestDefaultWatcherScanFilesInDirectory_Start(t *testing.T) {
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInDirectory,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -172,6 +284,7 @@ func TestDefaultWatcherScanFilesInDirectory_StartMultipleTimes(t *testing.T) {
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInDirectory,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -211,6 +324,7 @@ func TestDefaultWatcherScanFilesInDirectory_Stop(t *testing.T) {
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInDirectory,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -247,6 +361,7 @@ func TestDefaultWatcherScanFilesInDirectory_StopWithoutStart(t *testing.T) {
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInDirectory,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -281,6 +396,7 @@ func TestDefaultWatcherScanFilesInDirectory_StartStopStartStop(t *testing.T) {
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInDirectory,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -363,6 +479,7 @@ func TestDefaultWatcherScanFilesInDirectory_StopMultipleTimes(t *testing.T) {
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInDirectory,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -403,6 +520,7 @@ func TestDefaultWatcherScanFilesInDirectory_Destroy(t *testing.T) {
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInDirectory,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -452,6 +570,7 @@ func TestDefaultWatcherScanFilesInDirectory_DestroyMultipleTimes(t *testing.T) {
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInDirectory,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -505,6 +624,7 @@ func TestDefaultWatcherScanFilesInSubdirectories_Start(t *testing.T) {
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInSubdirectories,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -547,6 +667,7 @@ func TestDefaultWatcherScanFilesInSubdirectories_StartMultipleTimes(t *testing.T
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInSubdirectories,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -586,6 +707,7 @@ func TestDefaultWatcherScanFilesInSubdirectories_Stop(t *testing.T) {
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInSubdirectories,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -622,6 +744,7 @@ func TestDefaultWatcherScanFilesInSubdirectories_StopWithoutStart(t *testing.T)
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInSubdirectories,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -656,6 +779,7 @@ func TestDefaultWatcherScanFilesInSubdirectories_StartStopStartStop(t *testing.T
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInSubdirectories,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -740,6 +864,7 @@ func TestDefaultWatcherScanFilesInSubdirectories_StopMultipleTimes(t *testing.T)
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInSubdirectories,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -782,6 +907,7 @@ func TestDefaultWatcherScanFilesInSubdirectories_Destroy(t *testing.T) {
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInSubdirectories,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -833,6 +959,7 @@ func TestDefaultWatcherScanFilesInSubdirectories_DestroyMultipleTimes(t *testing
 		ScanCriteria: []string{searchFileExt},
 		Changes:      make(chan Change),
 		ScanFunc:     ScanFilesInSubdirectories,
+		Backend:      PollingBackend,
 	}
 	w, err := NewWatcher(config)
 	if err != nil {
@@ -893,3 +1020,381 @@ func testDataDirPath() string {
 
 	return final
 }
+
+// tempDataDirPath copies the test data directory to a temporary
+// directory that tests can safely modify.
+func tempDataDirPath(t *testing.T) string {
+	final := t.TempDir()
+
+	err := copyDir(testDataDirPath(), final)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return final
+}
+
+func copyDir(src string, dst string) error {
+	infos, err := ioutil.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		srcPath := path.Join(src, info.Name())
+		dstPath := path.Join(dst, info.Name())
+
+		if info.IsDir() {
+			err = os.Mkdir(dstPath, 0700)
+			if err != nil {
+				return err
+			}
+
+			err = copyDir(srcPath, dstPath)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		raw, err := ioutil.ReadFile(srcPath)
+		if err != nil {
+			return err
+		}
+
+		err = ioutil.WriteFile(dstPath, raw, 0600)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestDefaultWatcher_CreatedFiles(t *testing.T) {
+	dirPath := t.TempDir()
+	existing := path.Join(dirPath, "existing.txt")
+	writeTestFile(t, existing, "hello")
+
+	w := newDefaultWatcher(Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{searchFileExt, ".cfg"},
+		ScanFunc:     ScanFilesInDirectory,
+	})
+
+	change := w.scan(w.config)
+	if len(change.CreatedFilePaths()) != 0 {
+		t.Fatal("Files present at the first scan were reported as created -", change.CreatedFilePaths())
+	}
+
+	if len(change.UpdatedFilePaths()) != 1 || change.UpdatedFilePaths()[0] != existing {
+		t.Fatal("Did not get expected updated file paths -", change.UpdatedFilePaths())
+	}
+
+	newTxt := path.Join(dirPath, "new.txt")
+	writeTestFile(t, newTxt, "hello")
+	newCfg := path.Join(dirPath, "new.cfg")
+	writeTestFile(t, newCfg, "hello")
+
+	change = w.scan(w.config)
+	if len(change.UpdatedFilePaths()) != 0 {
+		t.Fatal("New files were reported as updated -", change.UpdatedFilePaths())
+	}
+
+	if len(change.CreatedFilePaths()) != 2 {
+		t.Fatal("Did not get expected created file paths -", change.CreatedFilePaths())
+	}
+
+	withSuffixes := change.CreatedFilePathsWithSuffixes([]string{".cfg"})
+	if len(withSuffixes) != 1 || withSuffixes[0] != newCfg {
+		t.Fatal("Did not get expected created file paths with suffixes -", withSuffixes)
+	}
+
+	withoutSuffixes := change.CreatedFilePathsWithoutSuffixes([]string{".cfg"})
+	if len(withoutSuffixes) != 1 || withoutSuffixes[0] != newTxt {
+		t.Fatal("Did not get expected created file paths without suffixes -", withoutSuffixes)
+	}
+}
+
+func TestDefaultWatcher_AttributeChangedFiles(t *testing.T) {
+	dirPath := t.TempDir()
+	filePath := path.Join(dirPath, "file.txt")
+	writeTestFile(t, filePath, "hello")
+
+	w := newDefaultWatcher(Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{searchFileExt},
+		ScanFunc:     ScanFilesInDirectory,
+	})
+
+	w.scan(w.config)
+	if w.last.FilePathsToInfo[filePath].Mode.Perm() != 0600 {
+		t.Fatal("Got unexpected mode -", w.last.FilePathsToInfo[filePath].Mode)
+	}
+
+	if uid := w.last.FilePathsToInfo[filePath].Uid; int(uid) != os.Getuid() {
+		t.Fatal("Got unexpected uid -", uid)
+	}
+
+	err := os.Chmod(filePath, 0644)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	change := w.scan(w.config)
+	if len(change.UpdatedFilePaths()) != 0 {
+		t.Fatal("Attribute change was reported as an update -", change.UpdatedFilePaths())
+	}
+
+	if len(change.AttributeChangedFilePaths()) != 1 || change.AttributeChangedFilePaths()[0] != filePath {
+		t.Fatal("Did not get expected attribute changed file paths -", change.AttributeChangedFilePaths())
+	}
+
+	writeTestFile(t, filePath, "goodbye")
+
+	change = w.scan(w.config)
+	if len(change.AttributeChangedFilePaths()) != 0 {
+		t.Fatal("Content change was reported as an attribute change -", change.AttributeChangedFilePaths())
+	}
+
+	if len(change.UpdatedFilePaths()) != 1 {
+		t.Fatal("Did not get expected updated file paths -", change.UpdatedFilePaths())
+	}
+}
+
+func TestDefaultWatcher_InitialScan(t *testing.T) {
+	dirPath := t.TempDir()
+	first := path.Join(dirPath, "first.txt")
+	writeTestFile(t, first, "hello")
+
+	config := Config{
+		RefreshDelay: 50 * time.Millisecond,
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{searchFileExt},
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesInDirectory,
+		Backend:      PollingBackend,
+		InitialScan:  InitialScanExisting,
+	}
+	w, err := NewWatcher(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer w.Destroy()
+
+	w.Start()
+
+	change := receiveChange(t, config.Changes)
+	if len(change.UpdatedFilePaths()) != 0 {
+		t.Fatal("Existing files were reported as updated -", change.UpdatedFilePaths())
+	}
+
+	if len(change.ExistingFilePaths()) != 1 || change.ExistingFilePaths()[0] != first {
+		t.Fatal("Did not get expected existing file paths -", change.ExistingFilePaths())
+	}
+
+	w.Stop()
+
+	second := path.Join(dirPath, "second.txt")
+	writeTestFile(t, second, "hello")
+
+	w.Start()
+
+	change = receiveChange(t, config.Changes)
+	if len(change.ExistingFilePaths()) != 1 || change.ExistingFilePaths()[0] != second {
+		t.Fatal("Did not get expected existing file paths after restart -", change.ExistingFilePaths())
+	}
+}
+
+func TestDefaultWatcher_InitialScanBaseline(t *testing.T) {
+	dirPath := t.TempDir()
+	writeTestFile(t, path.Join(dirPath, "first.txt"), "hello")
+
+	config := Config{
+		RefreshDelay: 50 * time.Millisecond,
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{searchFileExt},
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesInDirectory,
+		Backend:      PollingBackend,
+		InitialScan:  InitialScanBaseline,
+	}
+	w, err := NewWatcher(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer w.Destroy()
+
+	w.Start()
+
+	// Make sure that the first scan happened.
+	time.Sleep(100 * time.Millisecond)
+
+	second := path.Join(dirPath, "second.txt")
+	writeTestFile(t, second, "hello")
+
+	change := receiveChange(t, config.Changes)
+	if len(change.UpdatedFilePaths()) != 0 || len(change.ExistingFilePaths()) != 0 {
+		t.Fatal("Baseline files were reported -", change.UpdatedFilePaths(), change.ExistingFilePaths())
+	}
+
+	if len(change.CreatedFilePaths()) != 1 || change.CreatedFilePaths()[0] != second {
+		t.Fatal("Did not get expected created file paths -", change.CreatedFilePaths())
+	}
+}
+
+func receiveChange(t *testing.T, changes chan Change) Change {
+	select {
+	case change := <-changes:
+		if change.IsErr() {
+			t.Fatal(change.ErrDetails())
+		}
+		return change
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for a change")
+	}
+
+	return nil
+}
+
+func TestDefaultWatcher_Baseline(t *testing.T) {
+	dirPath := t.TempDir()
+	unchanged := path.Join(dirPath, "unchanged.txt")
+	writeTestFile(t, unchanged, "hello")
+	modified := path.Join(dirPath, "modified.txt")
+	writeTestFile(t, modified, "hello")
+	created := path.Join(dirPath, "created.txt")
+	writeTestFile(t, created, "hello")
+	deleted := path.Join(dirPath, "deleted.txt")
+
+	info, err := os.Stat(unchanged)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	w := newDefaultWatcher(Config{
+		RootDirPath:  dirPath,
+		ScanCriteria: []string{searchFileExt},
+		ScanFunc:     ScanFilesInDirectory,
+		Baseline: ScanResult{
+			FilePathsToInfo: map[string]MatchInfo{
+				unchanged: {Path: unchanged, ModTime: info.ModTime(), Size: info.Size()},
+				modified:  {Path: modified, ModTime: info.ModTime().Add(-time.Hour), Size: info.Size()},
+				deleted:   {ModTime: info.ModTime(), Size: info.Size()},
+			},
+		},
+	})
+
+	change := w.scan(w.config)
+
+	if len(change.UpdatedFilePaths()) != 1 || change.UpdatedFilePaths()[0] != modified {
+		t.Fatal("Did not get expected updated file paths -", change.UpdatedFilePaths())
+	}
+
+	if len(change.CreatedFilePaths()) != 1 || change.CreatedFilePaths()[0] != created {
+		t.Fatal("Did not get expected created file paths -", change.CreatedFilePaths())
+	}
+
+	if len(change.DeletedFilePaths()) != 1 || change.DeletedFilePaths()[0] != deleted {
+		t.Fatal("Did not get expected deleted file paths -", change.DeletedFilePaths())
+	}
+
+	if len(change.AttributeChangedFilePaths()) != 0 {
+		t.Fatal("Baseline files were reported as attribute changed -", change.AttributeChangedFilePaths())
+	}
+
+	change = w.scan(w.config)
+	if change.hasChanges() {
+		t.Fatal("Second scan reported changes")
+	}
+
+	config := w.config
+	config.Changes = make(chan Change)
+	config.Baseline.FilePathsToInfo[deleted] = MatchInfo{Path: unchanged}
+
+	_, err = NewWatcher(config)
+	if err == nil {
+		t.Fatal("Mismatched baseline path did not generate an error")
+	}
+}
+
+func TestDefaultWatcher_FailedRoot(t *testing.T) {
+	firstDirPath := t.TempDir()
+	secondDirPath := path.Join(t.TempDir(), "second")
+	kept := path.Join(secondDirPath, "kept.txt")
+	writeTestFile(t, kept, "hello")
+
+	config := Config{
+		RefreshDelay: 50 * time.Millisecond,
+		RootDirPath:  firstDirPath,
+		Roots:        []Root{{Path: secondDirPath}},
+		ScanCriteria: []string{searchFileExt},
+		Changes:      make(chan Change),
+		ScanFunc:     ScanFilesInDirectory,
+		Backend:      PollingBackend,
+	}
+	w, err := NewWatcher(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	w.Start()
+	defer w.Destroy()
+
+	receiveChange(t, config.Changes)
+
+	err = os.Rename(secondDirPath, secondDirPath+".old")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	created := path.Join(firstDirPath, "created.txt")
+	writeTestFile(t, created, "hello")
+
+	var change Change
+	failed := false
+
+	for change == nil {
+		select {
+		case c := <-config.Changes:
+			if c.IsErr() {
+				failed = true
+			} else {
+				change = c
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for a change")
+		}
+	}
+
+	if !failed {
+		t.Fatal("Missing root directory was not reported as an error")
+	}
+
+	if len(change.CreatedFilePaths()) != 1 || change.CreatedFilePaths()[0] != created {
+		t.Fatal("Did not get expected created file paths -", change.CreatedFilePaths())
+	}
+
+	if len(change.DeletedFilePaths()) != 0 {
+		t.Fatal("Files in missing root directory were reported as deleted -", change.DeletedFilePaths())
+	}
+
+	err = os.Rename(secondDirPath+".old", secondDirPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	timeout := time.After(300 * time.Millisecond)
+
+	for {
+		select {
+		case c := <-config.Changes:
+			if !c.IsErr() {
+				t.Fatal("Got unexpected change -", c.CreatedFilePaths(), c.DeletedFilePaths())
+			}
+		case <-timeout:
+			return
+		}
+	}
+}